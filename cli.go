@@ -17,12 +17,13 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"atomicgo.dev/cursor"
-	"atomicgo.dev/keyboard"
 	"atomicgo.dev/keyboard/keys"
 	"github.com/gookit/color"
 )
@@ -49,133 +50,337 @@ var TabWidth int = 4
 // Padding used by tabwriter
 var Padding int = 1
 
+// MaxCandidates represents maximum number of shown completion candidates
+var MaxCandidates = 100
+
 // helper function to handle keyboard input
 //gocyclo:ignore
 func keysHandler(ch chan<- string) {
-	var pos, hpos int
-	var cmd []string
+	var hpos int
+	var secret []string
+	var search *historySearch
+	var vi viMode
+	var ctrlX bool
+	line := &LineBuffer{}
 	history := ReadHistory()
+	raw := make(map[string]string) // raw history entries with DB passwords
 	if len(history) > 0 {
 		hpos = len(history)
 	}
 
+	// statements are passed to command handler by separate goroutine, such
+	// that keyboard handler is not blocked while command handler reads secret
+	// input, e.g. password of connect statement followed by other statements,
+	// the sent channel is closed when all previous statements are passed
+	sent := make(chan bool)
+	close(sent)
+	send := func(stms ...string) {
+		prev, next := sent, make(chan bool)
+		sent = next
+		go func() {
+			<-prev
+			for _, stm := range stms {
+				ch <- stm
+			}
+			close(next)
+		}()
+	}
+
+	// edit current line or last statement in external editor and execute edited statements
+	editAndRun := func(text string) {
+		if text == "" {
+			text = unmaskHistory(raw, lastStatement(history))
+		}
+		stms, err := editStatements(text)
+		line.Reset()
+		vi = viMode{}
+		if err != nil {
+			fmt.Println()
+			printError(err)
+			view.Reset()
+			redrawLine(line)
+			return
+		}
+		redrawLine(line)
+		if len(stms) == 0 {
+			return
+		}
+		fmt.Print(strings.Join(stms, ";\n") + ";")
+		for _, stm := range stms {
+			history = addHistory(history, raw, inputLine(stm))
+		}
+		send(stms...)
+	}
+
+	// draw search prompt and found history entry
+	drawSearch := func() {
+		prompt, match := search.Prompt(), displayText(search.Match(history))
+		view.Draw(prompt, prompt+match, match, len([]rune(match)))
+	}
+
 	// start detecting user input command
 	if COLOR {
 		color.Info.Printf(PROMPT)
 	} else {
 		fmt.Printf(PROMPT)
 	}
-	err := keyboard.Listen(func(key keys.Key) (stop bool, err error) {
+	saveTermState()
+	atomic.StoreInt32(&keyboardMode, 1)
+	defer atomic.StoreInt32(&keyboardMode, 0)
+	err := listenKeys(func(key keys.Key) (stop bool, err error) {
+
+		// read secret input, e.g. password, without echo
+		if atomic.LoadInt32(&secretMode) == 1 {
+			switch key.Code {
+			case keys.RuneKey, keys.Space:
+				secret = append(secret, key.String())
+			case keyPaste:
+				secret = append(secret, pastedText(key))
+			case keys.Backspace:
+				if len(secret) > 0 {
+					secret = secret[:len(secret)-1]
+				}
+			case keys.Enter, keys.CtrlC:
+				if key.Code == keys.CtrlC {
+					secret = []string{}
+				}
+				atomic.StoreInt32(&secretMode, 0)
+				SECRET <- strings.Join(secret, "")
+				secret = []string{}
+			}
+			return false, nil
+		}
 
-		switch key.Code {
-		case keys.RuneKey:
-			insert := false
-			if len(cmd) > pos {
-				cmd = append(cmd[:pos+1], cmd[pos:]...)
-				cmd[pos] = key.String()
-				insert = true
+		// terminal is resized, redraw wrapped input line for new terminal width
+		if key.Code == keyResize {
+			view.Resize()
+			if search != nil {
+				drawSearch()
 			} else {
-				cmd = append(cmd, key.String())
+				redrawLine(line)
 			}
-			pos += 1
-			cursor.StartOfLine()
-			if COLOR {
-				fmt.Printf(color.Info.Sprintf(PROMPT) + strings.Join(cmd, ""))
-			} else {
-				fmt.Printf(PROMPT + strings.Join(cmd, ""))
+			return false, nil
+		}
+
+		// reverse incremental history search, see Ctrl-R
+		if search != nil {
+			switch key.Code {
+			case keys.CtrlR:
+				search.Next(history)
+			case keys.RuneKey, keys.Space:
+				search.Type(history, string(key.Runes))
+			case keyPaste:
+				search.Type(history, pastedText(key))
+			case keys.Backspace:
+				search.Backspace(history)
+			case keys.Escape, keys.CtrlG, keys.CtrlC:
+				// cancel search and restore original input
+				search = nil
+				redrawLine(line)
+				return false, nil
+			default:
+				// accept found entry, Enter executes it and other keys start its editing
+				if match := search.Match(history); match != "" {
+					line.Set(match)
+				}
+				search = nil
+				redrawLine(line)
 			}
-			if insert {
-				cursor.Left(len(cmd) - pos)
+			if search != nil {
+				drawSearch()
+				return false, nil
 			}
-		case keys.Left:
-			if pos > 0 {
-				cursor.Left(1)
-				pos -= 1
+		}
+
+		// vi editing mode, keys of insert mode are handled as in emacs mode
+		if EDITMODE == "vi" && !vi.normal && key.Code == keys.Escape {
+			vi.Escape(line)
+			redrawLine(line)
+			return false, nil
+		}
+		if EDITMODE == "vi" && vi.normal {
+			switch key.Code {
+			case keys.RuneKey:
+				action := viRedraw
+				for _, r := range key.Runes {
+					if action = vi.Command(line, string(r)); action != viRedraw {
+						break
+					}
+				}
+				switch action {
+				case viSearch:
+					key = keys.Key{Code: keys.CtrlR}
+				case viHistoryPrev:
+					key = keys.Key{Code: keys.Up}
+				case viHistoryNext:
+					key = keys.Key{Code: keys.Down}
+				case viEdit:
+					editAndRun(line.String())
+					hpos = len(history)
+					return false, nil
+				default:
+					redrawLine(line)
+					return false, nil
+				}
+			case keys.Backspace:
+				key = keys.Key{Code: keys.Left}
+			case keys.Space:
+				key = keys.Key{Code: keys.Right}
+			case keys.Left, keys.Right, keys.Up, keys.Down, keys.Home, keys.End,
+				keys.Delete, keys.Enter, keys.CtrlR, keys.CtrlL, keys.CtrlC, keys.CtrlQ, keyPaste:
+			default:
+				return false, nil
 			}
-		case keys.Right:
-			if pos <= len(cmd) {
-				cursor.Right(1)
-				pos += 1
+		}
+
+		// Ctrl-X Ctrl-E edits current line in external editor
+		if ctrlX {
+			ctrlX = false
+			if key.Code == keys.CtrlE {
+				editAndRun(line.String())
+				hpos = len(history)
+				return false, nil
 			}
+		}
+
+		switch key.Code {
+		case keys.CtrlX:
+			ctrlX = true
+			return false, nil
+		case keys.CtrlR:
+			search = newHistorySearch(history)
+			drawSearch()
+			return false, nil
+		case keys.RuneKey:
+			if !key.AltPressed {
+				line.Insert(string(key.Runes))
+				break
+			}
+			switch string(key.Runes) {
+			case "b":
+				line.WordLeft()
+			case "f":
+				line.WordRight()
+			case "d":
+				line.KillWordForward()
+			default:
+				return false, nil
+			}
+		case keys.Space:
+			line.Insert(" ")
+		case keyPaste:
+			// pasted text is inserted as is and executed by Enter
+			line.Insert(pastedText(key))
+		case keys.Left, keys.CtrlB:
+			if key.AltPressed {
+				line.WordLeft()
+			} else {
+				line.Left()
+			}
+		case keys.Right, keys.CtrlF:
+			if key.AltPressed {
+				line.WordRight()
+			} else {
+				line.Right()
+			}
+		case keys.CtrlLeft:
+			line.WordLeft()
+		case keys.CtrlRight:
+			line.WordRight()
+		case keys.Home, keys.CtrlA:
+			line.Home()
+		case keys.End, keys.CtrlE:
+			line.End()
 		case keys.Up:
 			if hpos > 0 {
 				hpos -= 1
 			}
 			if len(history) > 0 && hpos < len(history) {
-				cursor.StartOfLine()
-				cursor.ClearLine()
-				cmd = strings.Split(history[hpos], "")
-				if COLOR {
-					fmt.Printf(color.Info.Sprintf(PROMPT) + strings.Join(cmd, ""))
-				} else {
-					fmt.Printf(PROMPT + strings.Join(cmd, ""))
-				}
-				pos = len(cmd)
+				line.Set(history[hpos])
 			}
 		case keys.Down:
 			if hpos < len(history) {
 				hpos += 1
 			}
 			if len(history) > 0 && hpos < len(history) {
-				cursor.StartOfLine()
-				cursor.ClearLine()
-				cmd = strings.Split(history[hpos], "")
-				if COLOR {
-					fmt.Printf(color.Info.Sprintf(PROMPT) + strings.Join(cmd, ""))
-				} else {
-					fmt.Printf(PROMPT + strings.Join(cmd, ""))
-				}
-				pos = len(cmd)
+				line.Set(history[hpos])
 			}
-		case keys.Space:
-			cursor.Right(1)
-			cmd = append(cmd, " ")
-			pos += 1
-		case keys.Backspace:
-			if pos > 0 {
-				front := cmd[:pos-1]
-				var rest []string
-				if pos < len(cmd) {
-					rest = cmd[pos:]
+		case keys.Backspace, keys.CtrlH:
+			if key.AltPressed {
+				line.KillPrevWord()
+			} else {
+				line.Backspace()
+			}
+		case keys.Delete, keys.CtrlD:
+			if key.AltPressed {
+				line.KillWordForward()
+			} else {
+				line.Delete()
+			}
+		case keys.CtrlW:
+			line.KillWordBackward()
+		case keys.CtrlK:
+			line.KillToEnd()
+		case keys.CtrlU:
+			line.KillToStart()
+		case keys.CtrlY:
+			line.Yank()
+		case keys.CtrlT:
+			line.Transpose()
+		case keys.Tab:
+			start, names := completeLine(line.String(), line.Pos())
+			if len(names) == 1 {
+				text := names[0]
+				if !strings.HasSuffix(text, "=") && !strings.HasSuffix(text, string(filepath.Separator)) {
+					text += " "
 				}
-				pos -= 1
-				cursor.StartOfLine()
-				cursor.ClearLine()
-				cmd = front
-				cmd = append(cmd, rest...)
-				if COLOR {
-					fmt.Printf(color.Info.Sprintf(PROMPT) + strings.Join(cmd, ""))
+				line.Replace(start, text)
+			} else if len(names) > 1 {
+				if prefix := commonPrefix(names); len([]rune(prefix)) > line.Pos()-start {
+					line.Replace(start, prefix)
 				} else {
-					fmt.Printf(PROMPT + strings.Join(cmd, ""))
-				}
-				if len(rest) > 0 {
-					cursor.Left(len(rest))
+					view.Finish()
+					printCandidates(names)
 				}
 			}
-		case keys.CtrlA:
-			cursor.StartOfLine()
-			cursor.Right(len(PROMPT))
-			pos = 0
-		case keys.CtrlE:
-			cursor.Right(len(cmd) - pos)
-			pos = len(cmd)
+		case keys.CtrlL:
+			// clear screen, current line is shown at its top
+			fmt.Print("\033[H\033[2J")
+			view.Reset()
 		case keys.CtrlC:
 			// copy to clipboard
+			return false, nil
 		case keys.CtrlQ:
 			FlushHistory(history)
 			reset()
 			return true, nil
 		case keys.Enter:
-			command := strings.Join(cmd, "")
-			history = append(history, command)
+			// command output starts below all rows of wrapped line
+			view.Finish()
+			command := unmaskHistory(raw, line.String())
+			history = addHistory(history, raw, command)
 			hpos = len(history) - 1
-			cmd = []string{}
+			line.Reset()
+			vi = viMode{}
 			if command == "history" {
 				fmt.Println()
 				for idx, cmd := range history {
 					fmt.Printf("%d %s\n", idx, cmd)
 				}
-				ch <- "" // send empty command
+				send("") // send empty command
+			} else if isEditCommand(command) {
+				editAndRun("")
+			} else if strings.Contains(command, "\n") {
+				// pasted multi-line text is executed statement by statement
+				history = history[:len(history)-1]
+				stms := splitStatements(command)
+				if len(stms) == 0 {
+					send("")
+				}
+				for _, stm := range stms {
+					history = addHistory(history, raw, inputLine(stm))
+				}
+				send(stms...)
 			} else if strings.HasPrefix(command, "!") {
 				// execute specific command
 				arr := strings.Split(command, "!")
@@ -183,8 +388,7 @@ func keysHandler(ch chan<- string) {
 					idxStr := strings.Trim(arr[1], " ")
 					if idx, err := strconv.Atoi(idxStr); err == nil {
 						if idx < len(history) {
-							c := history[idx]
-							ch <- c
+							send(unmaskHistory(raw, history[idx]))
 						}
 					}
 				}
@@ -192,13 +396,21 @@ func keysHandler(ch chan<- string) {
 				if command == "exit" || command == "quit" {
 					FlushHistory(history)
 					fmt.Println()
+					// wait until pending statements are passed to command handler
+					<-sent
 					return true, nil
 				}
-				ch <- command
+				send(command)
 			}
-			pos = 0
-			hpos += 1
+			hpos = len(history)
+			return false, nil
+		default:
+			return false, nil
+		}
+		if EDITMODE == "vi" && vi.normal {
+			line.ViClamp()
 		}
+		redrawLine(line)
 		return false, nil
 	})
 	if err != nil {
@@ -206,50 +418,114 @@ func keysHandler(ch chan<- string) {
 	}
 }
 
+// helper function to redraw prompt and edited line and place cursor at its position
+func redrawLine(line *LineBuffer) {
+	text := line.String()
+	styled := PROMPT + displayText(text)
+	if COLOR {
+		styled = color.Info.Sprintf(PROMPT) + displayText(highlight(text))
+	}
+	view.Draw(PROMPT, styled, displayText(text), line.Pos())
+}
+
+// helper function to print completion candidates below the input line
+func printCandidates(names []string) {
+	fmt.Println()
+	if len(names) > MaxCandidates {
+		fmt.Printf("%s ... and %d more\n", strings.Join(names[:MaxCandidates], "  "), len(names)-MaxCandidates)
+		return
+	}
+	fmt.Println(strings.Join(names, "  "))
+}
+
 // helper function show usage
 func showUsage() {
+	fmt.Println("sqlshell  backends:", strings.Join(dialectNames(), ", "))
 	fmt.Println("sqlshell  commands:")
 	fmt.Println("help      show this message")
 	fmt.Println("history   set or show history of used commands")
 	fmt.Println("!<number> execute specific command from the history")
+	fmt.Println("edit      edit last statement in $EDITOR and execute it, Ctrl-X Ctrl-E edits current line")
+	fmt.Println("Tab       complete commands, set options, SQL keywords, table, column and file names")
+	fmt.Println("refresh   reload schema cache of current connection used by tab completion")
+	fmt.Println("Ctrl-R    reverse search of the history, Enter runs found command, Esc or Ctrl-C cancels")
+	fmt.Println("          line editing keys: Ctrl-A/E, Alt-B/F, Ctrl-W, Alt-D, Ctrl-K/U/Y/T/L, see README")
 	fmt.Println("quit      exit the sqlshell")
 	fmt.Println("exit      exit the sqlshell")
+	fmt.Println("connect name=<name> uri=<dburi> [prompt=<prompt>]")
+	fmt.Println("          open new named DB connection and switch to it")
+	fmt.Println("connect @<profile>  connect to DB using connection profile")
+	fmt.Println("use <name>          switch to given DB connection")
+	fmt.Println("connections         list DB connections")
+	fmt.Println("disconnect <name>   close given DB connection")
+	fmt.Println("tables              list tables and views of current DB")
+	fmt.Println("describe <table>    describe columns of given table")
+	fmt.Println("explain <sql>       show query plan of given SQL statement")
+	fmt.Println("export format=<fmt> file=<file> [sheet=<name>] <sql>")
+	fmt.Println("                    export results of SELECT statement into parquet, arrow or xlsx file")
+	fmt.Println("                    xlsx exports into existing file add new sheets")
 	fmt.Println("set <cmd> perform set command")
-	fmt.Println("          supported commands: format, connect, index, pager, limit, history")
+	fmt.Println("          supported commands: format, connect, index, pager, limit, history,")
+	fmt.Println("          pushdown, fetchfirst, showsql, null, binary, timeformat, showtypes,")
+	fmt.Println("          maxwidth, wrap, fit, autopairs, table, xmlroot, xmlrow, editmode")
 	fmt.Println("set format=...    set output database format")
-	fmt.Println("                  formats: json,pairs,rows or rows:minwidth:tabwidth:padding:padchar")
+	fmt.Println("                  formats: json,ndjson,json-array,json-envelope,yaml,xml,insert,pairs,rows")
+	fmt.Println("                  or rows:minwidth:tabwidth:padding:padchar")
 	fmt.Println("                  pairs format will show key:value pairs of single DB row (default)")
 	fmt.Println("                  rows format will show record values as single DB row")
 	fmt.Println("                  json format will show DB record in JSON format")
+	fmt.Println("                  ndjson format will show DB records as newline delimited JSON")
+	fmt.Println("                  json-array format will show DB records as single JSON array")
+	fmt.Println("                  json-envelope format will show columns, types, rows, elapsed_ms")
+	fmt.Println("                  and rows_affected in single JSON document")
+	fmt.Println("                  errors are printed as JSON objects in JSON based formats")
+	fmt.Println("                  yaml format will show DB records as YAML list")
+	fmt.Println("                  xml format will show DB records as XML document")
+	fmt.Println("                  insert format will show DB records as INSERT statements")
 	fmt.Println("                  example: set format=rows:4:16:0")
-	fmt.Println("set connect=dburi connects to provided DB uri")
+	fmt.Println("set connect=dburi connects current connection to provided DB uri")
 	fmt.Println("                  example: set connect=sqlite:///tmp/file.db")
 	fmt.Println("set history=N     limits history to N lines")
 	fmt.Println("                  example: set history=1000")
 	fmt.Println("set index=N       starting index from DB output")
 	fmt.Println("                  example: set index=5")
-	fmt.Println("set limit=N       limit cut-off from DB output")
+	fmt.Println("set limit=N       limit cut-off from DB output, i.e. rows index..N-1 are shown")
 	fmt.Println("                  example: set limit=10 (default value)")
-	fmt.Println("set pager=N       shows N records per output")
-	fmt.Println("                  example: set pager=2")
+	fmt.Println("set pushdown=on   rewrite SELECT statements to fetch only index-limit rows from DB")
+	fmt.Println("set fetchfirst=on use FETCH FIRST syntax of ORACLE 12c+ instead of ROWNUM")
+	fmt.Println("set showsql=on    print SQL statements rewritten in pushdown mode")
+	fmt.Println("set null=<marker> string to show for NULL values")
+	fmt.Println("                  example: set null=NULL (default value)")
+	fmt.Println("set binary=<enc>  encoding of binary values, hex (default) or base64")
+	fmt.Println("set timeformat=.. layout of time values: rfc3339 (default), rfc3339nano, datetime,")
+	fmt.Println("                  unix, unixmilli or Go layout, e.g. set timeformat=2006-01-02 15:04:05")
+	fmt.Println("set showtypes=on  show database type, length, precision/scale and nullability of columns")
+	fmt.Println("set table=<name>  table name of insert format, by default it is guessed from SELECT statement")
+	fmt.Println("set xmlroot=..    name of root element of xml format, default results")
+	fmt.Println("set xmlrow=..     name of record element of xml format, default row")
+	fmt.Println("set maxwidth=N    limits width of columns in rows format, 0 means no limit (default)")
+	fmt.Println("set wrap=..       wrap wide cells: off (truncate with ellipsis, default), on or word")
+	fmt.Println("set fit=on        fit rows format into terminal width (default)")
+	fmt.Println("set autopairs=on  show records in pairs format when they do not fit into terminal")
+	fmt.Println("set editmode=vi   use vi key bindings in the prompt, Esc switches to normal mode,")
+	fmt.Println("                  v opens statement in $EDITOR (default is emacs)")
+	fmt.Println("set color         colored output and syntax highlighting of SQL statements in the prompt")
+	fmt.Println("set pager=N       shows N records per page and keeps DB cursor of SELECT statements open")
+	fmt.Println("                  example: set pager=20 (use 0 to disable paging)")
+	fmt.Println("next              show next page of last SELECT statement")
+	fmt.Println("prev              show previous page of last SELECT statement")
+	fmt.Println("page <N>          show N-th page of last SELECT statement")
+	fmt.Println("last              show last page of last SELECT statement")
 }
 
 // helper function to parse DB statement
 func parseDBStatement(cmd string) (string, []interface{}) {
 	var args []interface{}
-	cmd = strings.Replace(cmd, ";", "", -1)
+	// only trailing semicolons are removed, semicolons of string literals are kept
+	cmd = strings.TrimRight(strings.TrimSpace(cmd), "; ")
 	return cmd, args
 }
 
-// helper function to connect to DB
-func dbConnect(dburi string) {
-	db, dberr := dbInit(dburi)
-	if dberr != nil {
-		log.Fatal(dberr)
-	}
-	DB = db
-}
-
 // helper function to set DB format
 func setDBFormat(format string) {
 	arr := strings.Split(format, ":")
@@ -275,10 +551,16 @@ func setDBFormat(format string) {
 	}
 }
 
+// helper function to check if JSON based format is used
+func jsonFormat() bool {
+	return strings.HasPrefix(DBFORMAT, "json") || DBFORMAT == "ndjson"
+}
+
 // helper function to match DB statement
 func sqlCommand(cmd string) bool {
 	cmd = strings.ToLower(cmd)
 	if strings.HasPrefix(cmd, "select") ||
+		strings.HasPrefix(cmd, "with") ||
 		strings.HasPrefix(cmd, "insert") ||
 		strings.HasPrefix(cmd, "update") ||
 		strings.HasPrefix(cmd, "begin") ||
@@ -309,7 +591,7 @@ func cmdHandler(ch <-chan string, done <-chan bool) {
 				if err := execInput(input); err != nil {
 					//                     log.Fprintln(os.Stderr, err)
 					//                     log.Println("ERROR:", err)
-					color.Error.Println("ERROR:", err)
+					printError(err)
 				}
 			}
 			if COLOR {
@@ -340,6 +622,9 @@ func execInput(command string) error {
 	// check if we got SQL command
 	if sqlCommand(command) {
 		stm, args := parseDBStatement(command)
+		if ddlStatement(stm) {
+			defer refreshSchema()
+		}
 		return executeSQL(stm, args...)
 	}
 
@@ -351,10 +636,52 @@ func execInput(command string) error {
 
 	// check set command
 	if strings.HasPrefix(command, "set") {
-		setCommand(command)
+		return setCommand(command)
+	}
+
+	// check introspection commands
+	if command == "tables" {
+		return showTables()
+	}
+	if strings.HasPrefix(command, "describe ") {
+		return describeTable(strings.TrimPrefix(command, "describe "))
+	}
+	if strings.HasPrefix(strings.ToLower(command), "explain ") {
+		return explainStatement(command[len("explain "):])
+	}
+
+	// check refresh command
+	if strings.Trim(command, " ;") == "refresh" {
+		refreshSchema()
+		fmt.Println("schema cache of tab completion is cleared")
 		return nil
 	}
 
+	// check export command
+	if strings.HasPrefix(command, "export ") {
+		return exportCommand(command)
+	}
+
+	// check cursor page commands
+	if isPageCommand(strings.Trim(command, " ;")) {
+		return pageCommand(strings.Trim(command, " ;"))
+	}
+
+	// check connection commands
+	if command == "connections" {
+		showConnections()
+		return nil
+	}
+	if strings.HasPrefix(command, "connect ") {
+		return connectCommand(command)
+	}
+	if strings.HasPrefix(command, "use ") {
+		return dbUse(strings.Trim(strings.TrimPrefix(command, "use "), " ;"))
+	}
+	if strings.HasPrefix(command, "disconnect ") {
+		return dbDisconnect(strings.Trim(strings.TrimPrefix(command, "disconnect "), " ;"))
+	}
+
 	// Remove the newline character.
 	command = strings.TrimSuffix(command, "\n")
 
@@ -401,7 +728,7 @@ func execInput(command string) error {
   set limit=100
   set dbconnection=sqlite:///tmp/files.db
 */
-func setCommand(input string) {
+func setCommand(input string) error {
 	arr := strings.Split(input, "set ")
 	command := strings.Join(arr[1:], "")
 
@@ -412,22 +739,21 @@ func setCommand(input string) {
 			setDBFormat(arr[1])
 			fmt.Println("set DB format to", DBFORMAT)
 		} else {
-			fmt.Println("format  : json,cols,rows or rows:minwidth:tabwidth:padding:padchar")
+			fmt.Println("format  : json,ndjson,json-array,json-envelope,yaml,xml,insert,pairs,rows or rows:minwidth:tabwidth:padding:padchar")
 			fmt.Println("example : dbformat=rows:4:16:0")
 		}
-		return
+		return nil
 	}
 
 	// connect command
 	if strings.HasPrefix(command, "connect") {
-		arr := strings.Split(command, "=")
+		arr := strings.SplitN(command, "=", 2)
 		if len(arr) == 2 {
 			dburi := strings.Trim(arr[1], " ")
-			dbConnect(dburi)
-		} else {
-			fmt.Println("connect to provide DB uri, e.g. set connect sqlite:///path/file.db")
+			return dbConnect(CURRENT, dburi, "")
 		}
-		return
+		fmt.Println("connect to provide DB uri, e.g. set connect=sqlite:///path/file.db")
+		return nil
 	}
 
 	// history command
@@ -442,7 +768,7 @@ func setCommand(input string) {
 		} else {
 			fmt.Println("set history=N, where N is number of records to keep")
 		}
-		return
+		return nil
 	}
 
 	// index command
@@ -457,7 +783,7 @@ func setCommand(input string) {
 		} else {
 			fmt.Println("set index=N, where N is first record from DB output to print")
 		}
-		return
+		return nil
 	}
 
 	// limit command
@@ -470,27 +796,200 @@ func setCommand(input string) {
 				LIMIT = v
 			}
 		} else {
-			fmt.Println("set limit=N, where N is last record from DB output to print")
+			fmt.Println("set limit=N, where N is cut-off record number, i.e. records index..N-1 are printed")
 		}
-		return
+		return nil
 	}
 
 	// pager command
 	if strings.HasPrefix(command, "pager") {
 		arr := strings.Split(command, "=")
 		if len(arr) == 2 {
-			fmt.Println("Not implemented yet")
+			s := strings.Trim(arr[1], " ")
+			v, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("wrong pager value '%s'", s)
+			}
+			PAGER = v
+			if PAGER <= 0 {
+				closeCursor()
+			}
 		} else {
-			fmt.Println("set pager=N, where N is number of records to dump from DB output")
+			fmt.Println("set pager=N, where N is number of records per page, use 0 to disable paging")
 		}
-		return
+		return nil
+	}
+
+	// pushdown command
+	if strings.HasPrefix(command, "pushdown") {
+		return setFlag(command, &PUSHDOWN, "set pushdown=on|off, push index and limit down to DB by rewriting SELECT statements")
+	}
+
+	// fetchfirst command
+	if strings.HasPrefix(command, "fetchfirst") {
+		return setFlag(command, &FETCHFIRST, "set fetchfirst=on|off, use FETCH FIRST (ORACLE 12c+) instead of ROWNUM in pushdown mode")
+	}
+
+	// showsql command
+	if strings.HasPrefix(command, "showsql") {
+		return setFlag(command, &SHOWSQL, "set showsql=on|off, print SQL statements rewritten in pushdown mode")
+	}
+
+	// table command
+	if strings.HasPrefix(command, "table") {
+		arr := strings.SplitN(command, "=", 2)
+		if len(arr) == 2 {
+			INSERTTABLE = strings.Trim(arr[1], " ")
+		} else {
+			fmt.Println("set table=<name>, table name of insert format, empty value guesses it from SELECT statement")
+		}
+		return nil
+	}
+
+	// xmlroot command
+	if strings.HasPrefix(command, "xmlroot") {
+		arr := strings.SplitN(command, "=", 2)
+		if len(arr) == 2 && strings.Trim(arr[1], " ") != "" {
+			XMLROOT = strings.Trim(arr[1], " ")
+		} else {
+			fmt.Println("set xmlroot=<name>, name of root element of xml format")
+		}
+		return nil
+	}
+
+	// xmlrow command
+	if strings.HasPrefix(command, "xmlrow") {
+		arr := strings.SplitN(command, "=", 2)
+		if len(arr) == 2 && strings.Trim(arr[1], " ") != "" {
+			XMLROW = strings.Trim(arr[1], " ")
+		} else {
+			fmt.Println("set xmlrow=<name>, name of record element of xml format")
+		}
+		return nil
+	}
+
+	// maxwidth command
+	if strings.HasPrefix(command, "maxwidth") {
+		arr := strings.SplitN(command, "=", 2)
+		if len(arr) != 2 {
+			fmt.Println("set maxwidth=N, where N is maximum width of columns in rows format, 0 means no limit")
+			return nil
+		}
+		s := strings.Trim(arr[1], " ")
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			return fmt.Errorf("wrong maxwidth value '%s'", s)
+		}
+		MAXWIDTH = v
+		return nil
+	}
+
+	// wrap command
+	if strings.HasPrefix(command, "wrap") {
+		arr := strings.SplitN(command, "=", 2)
+		if len(arr) != 2 {
+			fmt.Println("set wrap=on|off|word, wrap wide cells in rows format or truncate them (off)")
+			return nil
+		}
+		val := strings.ToLower(strings.Trim(arr[1], " "))
+		if val != "on" && val != "off" && val != "word" {
+			return fmt.Errorf("wrong wrap value '%s', should be on, off or word", val)
+		}
+		WRAP = val
+		return nil
+	}
+
+	// editmode command
+	if strings.HasPrefix(command, "editmode") {
+		arr := strings.SplitN(command, "=", 2)
+		if len(arr) != 2 {
+			fmt.Println("set editmode=emacs|vi, key bindings of the prompt")
+			return nil
+		}
+		val := strings.ToLower(strings.Trim(arr[1], " "))
+		if val != "emacs" && val != "vi" {
+			return fmt.Errorf("wrong editmode value '%s', should be emacs or vi", val)
+		}
+		EDITMODE = val
+		return nil
+	}
+
+	// fit command
+	if strings.HasPrefix(command, "fit") {
+		return setFlag(command, &FIT, "set fit=on|off, fit rows format into terminal width")
+	}
+
+	// autopairs command
+	if strings.HasPrefix(command, "autopairs") {
+		return setFlag(command, &AUTOPAIRS, "set autopairs=on|off, use pairs format for records which do not fit into terminal")
+	}
+
+	// showtypes command
+	if strings.HasPrefix(command, "showtypes") {
+		return setFlag(command, &SHOWTYPES, "set showtypes=on|off, show database types of columns")
+	}
+
+	// null command
+	if strings.HasPrefix(command, "null") {
+		arr := strings.SplitN(command, "=", 2)
+		if len(arr) == 2 {
+			NULLMARKER = strings.Trim(arr[1], " ")
+		} else {
+			fmt.Println("set null=<marker>, where marker is shown for NULL values, e.g. set null=NULL")
+		}
+		return nil
+	}
+
+	// binary command
+	if strings.HasPrefix(command, "binary") {
+		arr := strings.SplitN(command, "=", 2)
+		if len(arr) != 2 {
+			fmt.Println("set binary=hex|base64, encoding of binary values")
+			return nil
+		}
+		val := strings.ToLower(strings.Trim(arr[1], " "))
+		if val != "hex" && val != "base64" {
+			return fmt.Errorf("wrong binary encoding '%s', should be hex or base64", val)
+		}
+		BINARYFORMAT = val
+		return nil
+	}
+
+	// timeformat command
+	if strings.HasPrefix(command, "timeformat") {
+		arr := strings.SplitN(command, "=", 2)
+		if len(arr) == 2 {
+			return setTimeFormat(arr[1])
+		}
+		fmt.Println("set timeformat=<layout>, where layout is rfc3339, rfc3339nano, datetime, unix, unixmilli or Go layout")
+		fmt.Println("example: set timeformat=2006-01-02 15:04:05")
+		return nil
 	}
 
 	// color command
 	if strings.HasPrefix(command, "color") {
 		COLOR = true
-		return
+		return nil
+	}
+	return nil
+}
+
+// helper function to set boolean flag from set command, e.g. set pushdown=on
+func setFlag(command string, flag *bool, help string) error {
+	arr := strings.SplitN(command, "=", 2)
+	if len(arr) != 2 {
+		fmt.Println(help)
+		return nil
 	}
+	switch strings.ToLower(strings.Trim(arr[1], " ")) {
+	case "on", "true", "yes", "1":
+		*flag = true
+	case "off", "false", "no", "0":
+		*flag = false
+	default:
+		return fmt.Errorf("wrong value '%s', should be on or off", strings.Trim(arr[1], " "))
+	}
+	return nil
 }
 
 // reset stdout/stderr and cursor terminal
@@ -0,0 +1,481 @@
+package main
+
+// completion module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// Tab completion of the prompt. Depending on the context it completes
+// - shell commands at the beginning of the line
+// - set options and their values
+// - connection names of use and disconnect commands
+// - table and view names after FROM, JOIN, INTO, UPDATE and describe
+// - column names of tables mentioned in the statement, e.g. t.<Tab>
+// - file paths and formats of export command
+// - SQL keywords
+//
+// Table and column names are kept in schema cache of every connection. It is
+// loaded lazily from introspection queries of the dialect and can be
+// refreshed via refresh command, it is also dropped after DDL statements.
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// SCHEMA represents schema cache of current connection
+var SCHEMA *Schema
+
+// SchemaTimeout represents timeout of introspection queries of tab completion
+var SchemaTimeout = 2 * time.Second
+
+// Schema represents lazily loaded table and column names of DB, it keeps DB
+// and dialect of its connection, i.e. completion does not use session globals
+type Schema struct {
+	mu      sync.Mutex          // guards cached names
+	db      *sql.DB             // database of the connection
+	dialect Dialect             // dialect of the connection
+	tables  []string            // table and view names
+	loaded  bool                // table names are loaded
+	columns map[string][]string // column names of tables, keys are lower case table names
+}
+
+// helper function to create schema cache of given DB
+func newSchema(db *sql.DB, dialect Dialect) *Schema {
+	return &Schema{db: db, dialect: dialect}
+}
+
+// Tables returns table and view names, they are loaded on first use
+func (s *Schema) Tables() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded && s.db != nil && s.dialect != nil {
+		s.tables = queryNames(s.db, s.dialect.TablesQuery(), "table_name")
+		s.loaded = true
+	}
+	return s.tables
+}
+
+// Columns returns column names of given table, they are loaded on first use
+func (s *Schema) Columns(table string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.ToLower(table)
+	if cols, ok := s.columns[key]; ok {
+		return cols
+	}
+	if s.db == nil || s.dialect == nil {
+		return nil
+	}
+	cols := queryNames(s.db, s.dialect.DescribeQuery(), "column_name", table)
+	if s.columns == nil {
+		s.columns = make(map[string][]string)
+	}
+	s.columns[key] = cols
+	return cols
+}
+
+// Reset drops cached names such that they are loaded again on next use
+func (s *Schema) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables = nil
+	s.loaded = false
+	s.columns = nil
+}
+
+// helper function to return schema cache of current connection
+func currentSchema() *Schema {
+	SESSIONLOCK.RLock()
+	defer SESSIONLOCK.RUnlock()
+	if SCHEMA == nil {
+		// no active connection
+		return &Schema{}
+	}
+	return SCHEMA
+}
+
+// helper function to refresh schema cache of current connection
+func refreshSchema() {
+	currentSchema().Reset()
+}
+
+// helper function to check if statement changes DB schema
+func ddlStatement(stm string) bool {
+	stm = strings.ToLower(strings.TrimSpace(stm))
+	for _, prefix := range []string{"create", "alter", "drop", "rename"} {
+		if strings.HasPrefix(stm, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// helper function to query names from given column of introspection query,
+// errors are ignored since completion should not disturb the user, and the
+// query is canceled after SchemaTimeout to not block the prompt
+func queryNames(db *sql.DB, query, column string, args ...interface{}) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), SchemaTimeout)
+	defer cancel()
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil
+	}
+	idx := 0
+	for i, col := range cols {
+		if strings.ToLower(col) == column {
+			idx = i
+		}
+	}
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	var names []string
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return names
+		}
+		switch v := values[idx].(type) {
+		case string:
+			names = append(names, v)
+		case []byte:
+			names = append(names, string(v))
+		}
+	}
+	return names
+}
+
+// ShellCommands represents commands completed at the beginning of the line
+var ShellCommands = []string{
+	"help", "history", "quit", "exit", "connect", "use", "connections",
+	"disconnect", "tables", "describe", "explain", "set", "export", "edit",
+	"refresh", "next", "prev", "page", "last",
+}
+
+// SetOptions represents options of set command
+var SetOptions = []string{
+	"format", "connect", "history", "index", "limit", "pager", "pushdown",
+	"fetchfirst", "showsql", "null", "binary", "timeformat", "showtypes",
+	"maxwidth", "wrap", "fit", "autopairs", "table", "xmlroot", "xmlrow",
+	"editmode",
+}
+
+// SetValues represents values of set options which have fixed set of values
+var SetValues = map[string][]string{
+	"format":     {"pairs", "rows", "json", "ndjson", "json-array", "json-envelope", "yaml", "xml", "insert"},
+	"pushdown":   {"on", "off"},
+	"fetchfirst": {"on", "off"},
+	"showsql":    {"on", "off"},
+	"showtypes":  {"on", "off"},
+	"fit":        {"on", "off"},
+	"autopairs":  {"on", "off"},
+	"binary":     {"hex", "base64"},
+	"timeformat": {"rfc3339", "rfc3339nano", "datetime", "unix", "unixmilli"},
+	"wrap":       {"on", "off", "word"},
+	"editmode":   {"emacs", "vi"},
+}
+
+// SQLKeywords represents SQL keywords used by completion
+var SQLKeywords = []string{
+	"ADD", "ALL", "ALTER", "AND", "AS", "ASC", "BEGIN", "BETWEEN", "BY",
+	"CASE", "CAST", "COALESCE", "COMMIT", "COUNT", "CREATE", "CROSS",
+	"DEFAULT", "DELETE", "DESC", "DISTINCT", "DROP", "ELSE", "END", "EXISTS",
+	"FETCH", "FIRST", "FROM", "FULL", "GROUP", "HAVING", "IN", "INDEX",
+	"INNER", "INSERT", "INTO", "IS", "JOIN", "KEY", "LEFT", "LIKE", "LIMIT",
+	"MAX", "MIN", "NEXT", "NOT", "NULL", "OFFSET", "ON", "OR", "ORDER",
+	"OUTER", "PRIMARY", "RIGHT", "ROLLBACK", "ROWS", "SELECT", "SET", "SUM",
+	"TABLE", "THEN", "UNION", "UPDATE", "VALUES", "VIEW", "WHEN", "WHERE",
+	"WITH",
+}
+
+// tableKeywords represents keywords which are followed by table names
+var tableKeywords = map[string]bool{
+	"from": true, "join": true, "into": true, "update": true, "table": true,
+}
+
+// helper function to check if given word is SQL keyword
+func isKeyword(word string) bool {
+	word = strings.ToUpper(word)
+	for _, kw := range SQLKeywords {
+		if kw == word {
+			return true
+		}
+	}
+	return false
+}
+
+// helper function to check if character is part of completed identifier
+func identRune(r rune) bool {
+	return r == '_' || r == '$' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// helper function to return completion candidates for the word before cursor,
+// it returns position where completed word starts and full candidate words
+func completeLine(text string, pos int) (int, []string) {
+	runes := []rune(text)
+	if pos > len(runes) {
+		pos = len(runes)
+	}
+	before := string(runes[:pos])
+	fields := strings.Fields(before)
+	// words before the one which is completed
+	words := fields
+	if len(fields) > 0 && !strings.HasSuffix(before, " ") {
+		words = fields[:len(fields)-1]
+	}
+
+	// file paths of export command, the word is delimited by spaces
+	if len(words) > 0 {
+		switch strings.ToLower(words[0]) {
+		case "export":
+			start := pos
+			for start > 0 && !unicode.IsSpace(runes[start-1]) {
+				start--
+			}
+			word := string(runes[start:pos])
+			if strings.HasPrefix(word, "file=") {
+				return start, prefixed("file=", completePath(strings.TrimPrefix(word, "file=")))
+			}
+			if strings.HasPrefix(word, "format=") {
+				var formats []string
+				for format := range ExportFormats {
+					formats = append(formats, format)
+				}
+				sort.Strings(formats)
+				return start, prefixed("format=", matches(strings.TrimPrefix(word, "format="), formats))
+			}
+			if strings.HasPrefix(word, "/") || strings.HasPrefix(word, "./") || strings.HasPrefix(word, "~") {
+				return start, completePath(word)
+			}
+		}
+	}
+
+	start := pos
+	for start > 0 && identRune(runes[start-1]) {
+		start--
+	}
+	word := string(runes[start:pos])
+
+	if len(words) == 0 {
+		return start, matches(word, append(append([]string{}, ShellCommands...), statementKeywords()...))
+	}
+	switch strings.ToLower(words[0]) {
+	case "set":
+		if len(words) > 1 {
+			return start, nil
+		}
+		// set option=value is completed as single word
+		start = pos
+		for start > 0 && !unicode.IsSpace(runes[start-1]) {
+			start--
+		}
+		word = string(runes[start:pos])
+		if arr := strings.SplitN(word, "=", 2); len(arr) == 2 {
+			return start, prefixed(arr[0]+"=", matches(arr[1], SetValues[strings.ToLower(arr[0])]))
+		}
+		return start, suffixed("=", matches(word, SetOptions))
+	case "use", "disconnect":
+		return start, matches(word, connectionNames())
+	case "describe":
+		return start, matches(word, currentSchema().Tables())
+	case "help", "history", "quit", "exit", "tables", "connections", "edit", "refresh":
+		return start, nil
+	}
+	// tables may be listed after the cursor, e.g. select u.na| from users u
+	stm := string(runes)
+	if idx := strings.Index(string(runes[pos:]), ";"); idx >= 0 {
+		stm = string(runes[:pos]) + string(runes[pos:])[:idx]
+	}
+	return start, completeSQL(stm, words, word)
+}
+
+// helper function to return keywords which start SQL statements
+func statementKeywords() []string {
+	return []string{"select", "insert", "update", "delete", "create", "alter", "drop", "with", "begin", "commit", "rollback"}
+}
+
+// helper function to complete word of SQL statement
+func completeSQL(stm string, words []string, word string) []string {
+	schema := currentSchema()
+	aliases := statementTables(stm)
+
+	// qualified column name, e.g. alias.column
+	if idx := strings.LastIndex(word, "."); idx > 0 {
+		prefix := word[:idx]
+		table := prefix
+		if t, ok := aliases[strings.ToLower(prefix)]; ok {
+			table = t
+		}
+		return prefixed(prefix+".", matches(word[idx+1:], schema.Columns(table)))
+	}
+
+	// table names follow FROM, JOIN, INTO and UPDATE keywords and commas after them
+	for idx := len(words) - 1; idx >= 0; idx-- {
+		w := strings.ToLower(strings.Trim(words[idx], ","))
+		if tableKeywords[w] {
+			if idx == len(words)-1 || strings.HasSuffix(words[len(words)-1], ",") {
+				return matches(word, schema.Tables())
+			}
+			break
+		}
+		if isKeyword(w) {
+			break
+		}
+	}
+
+	// columns of mentioned tables and keywords
+	var names []string
+	seen := make(map[string]bool)
+	for _, table := range aliases {
+		if seen[strings.ToLower(table)] {
+			continue
+		}
+		seen[strings.ToLower(table)] = true
+		names = append(names, schema.Columns(table)...)
+	}
+	names = append(names, SQLKeywords...)
+	return matches(word, names)
+}
+
+// helper function to return tables mentioned in statement, keys are lower
+// case table names and their aliases
+func statementTables(stm string) map[string]string {
+	tables := make(map[string]string)
+	// parentheses and semicolons are separate tokens which end list of tables
+	replacer := strings.NewReplacer("(", " ( ", ")", " ) ", ";", " ; ")
+	tokens := strings.Fields(replacer.Replace(stm))
+	for i := 0; i < len(tokens); i++ {
+		if !tableKeywords[strings.ToLower(tokens[i])] {
+			continue
+		}
+		// list of tables with optional aliases, e.g. FROM a x, b AS y
+		for i++; i < len(tokens); i++ {
+			name := strings.TrimSuffix(tokens[i], ",")
+			if name == "" || isKeyword(name) || strings.ContainsAny(name, "();") {
+				i--
+				break
+			}
+			tables[strings.ToLower(name)] = name
+			more := strings.HasSuffix(tokens[i], ",")
+			if !more && i+1 < len(tokens) {
+				next := tokens[i+1]
+				if strings.ToLower(next) == "as" && i+2 < len(tokens) {
+					i++
+					next = tokens[i+1]
+				}
+				alias := strings.TrimSuffix(next, ",")
+				if alias != "" && !isKeyword(alias) && !strings.ContainsAny(alias, "();") {
+					tables[strings.ToLower(alias)] = name
+					i++
+					more = strings.HasSuffix(next, ",")
+				}
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	return tables
+}
+
+// helper function to complete file path
+func completePath(word string) []string {
+	path := word
+	if strings.HasPrefix(path, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + strings.TrimPrefix(path, "~")
+		}
+	}
+	dir, base := filepath.Split(path)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+	// candidates keep the directory part as it was typed
+	typedDir := word[:len(word)-len(base)]
+	var out []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		out = append(out, typedDir+name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// helper function to return names which start with given prefix, the match
+// is case insensitive and upper case names follow case of lower case prefix
+func matches(prefix string, names []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	lower := strings.ToLower(prefix)
+	for _, name := range names {
+		if !strings.HasPrefix(strings.ToLower(name), lower) {
+			continue
+		}
+		if prefix != "" && prefix == lower && name == strings.ToUpper(name) {
+			name = strings.ToLower(name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// helper function to add prefix to all candidates
+func prefixed(prefix string, names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = prefix + name
+	}
+	return out
+}
+
+// helper function to add suffix to all candidates
+func suffixed(suffix string, names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = name + suffix
+	}
+	return out
+}
+
+// helper function to return longest common prefix of candidates
+func commonPrefix(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	prefix := []rune(names[0])
+	for _, name := range names[1:] {
+		runes := []rune(name)
+		n := 0
+		for n < len(prefix) && n < len(runes) && prefix[n] == runes[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
@@ -0,0 +1,45 @@
+package main
+
+// completion module tests
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestCompleteExport tests completion of formats and file paths of export command
+func TestCompleteExport(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"out.parquet", "out.xlsx", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", dir)
+	tests := []struct {
+		text  string
+		start int
+		want  []string
+	}{
+		{"export format=p", 7, []string{"format=parquet"}},
+		{"export format=xlsx file=" + dir + "/o", 19,
+			[]string{"file=" + dir + "/out.parquet", "file=" + dir + "/out.xlsx"}},
+		{"export file=" + dir + "/s", 7, []string{"file=" + dir + "/sub/"}},
+		{"export " + dir + "/out.x", 7, []string{dir + "/out.xlsx"}},
+		{"export ~/o", 7, []string{"~/out.parquet", "~/out.xlsx"}},
+		{"export file=~/.h", 7, []string{"file=~/.hidden"}},
+		{"export file=" + dir + "/none", 7, []string{}},
+	}
+	for _, tc := range tests {
+		start, names := completeLine(tc.text, len([]rune(tc.text)))
+		if start != tc.start || !reflect.DeepEqual(names, tc.want) {
+			t.Errorf("%q: expected %v at %d, got %v at %d", tc.text, tc.want, tc.start, names, start)
+		}
+	}
+}
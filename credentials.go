@@ -0,0 +1,254 @@
+package main
+
+// credentials module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// DB password is looked up in the following order:
+// - SQLSHELL_PASSWORD environment variable
+// - standard driver environment variable, e.g. PGPASSWORD or MYSQL_PWD
+// - output of password_command of connection profile, e.g. pass show db/prod
+// - pgpass-style file (hostname:port:database:username:password), i.e.
+//   passfile of connection profile, PGPASSFILE or ~/.pgpass for postgres
+// - interactive prompt with disabled echo, the password is empty if stdin is
+//   not a terminal, e.g. DB with trust authentication is used in a script
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/term"
+)
+
+// PasswordEnv represents sqlshell environment variable holding DB password
+const PasswordEnv = "SQLSHELL_PASSWORD"
+
+// SECRET represents channel to pass secret input, e.g. password, from keyboard handler
+var SECRET = make(chan string)
+
+// secretMode is set when keyboard handler should read secret input without echo
+var secretMode int32
+
+// keyboardMode is set when keyboard handler owns the terminal
+var keyboardMode int32
+
+// Credentials represents parameters used to look up DB password
+type Credentials struct {
+	Dialect         Dialect // database dialect
+	Host            string  // database host
+	Port            string  // database port
+	Database        string  // database name
+	User            string  // database user
+	PasswordCommand string  // command which prints password to stdout
+	PassFile        string  // pgpass-style password file
+}
+
+// Password looks up DB password for given credentials
+func (c *Credentials) Password() (string, error) {
+	if pwd := os.Getenv(PasswordEnv); pwd != "" {
+		return pwd, nil
+	}
+	if env := c.Dialect.PasswordEnv(); env != "" {
+		if pwd := os.Getenv(env); pwd != "" {
+			return pwd, nil
+		}
+	}
+	if c.PasswordCommand != "" {
+		return commandPassword(c.PasswordCommand)
+	}
+	for _, fname := range c.passFiles() {
+		if pwd, ok := c.passFilePassword(fname); ok {
+			return pwd, nil
+		}
+	}
+	if atomic.LoadInt32(&keyboardMode) == 0 && !term.IsTerminal(int(os.Stdin.Fd())) {
+		// password can't be asked, DB may not need it, e.g. postgres trust authentication
+		return "", nil
+	}
+	return readPassword(fmt.Sprintf("Password for %s: ", c.User))
+}
+
+// helper function to return list of pgpass-style files to look-up
+func (c *Credentials) passFiles() []string {
+	var files []string
+	if c.PassFile != "" {
+		files = append(files, expandHome(c.PassFile))
+	}
+	if c.Dialect.Name() == "postgres" {
+		if fname := os.Getenv("PGPASSFILE"); fname != "" {
+			files = append(files, fname)
+		} else if udir, err := os.UserHomeDir(); err == nil {
+			files = append(files, filepath.Join(udir, ".pgpass"))
+		}
+	}
+	return files
+}
+
+// helper function to match field of pgpass-style file
+func passFieldMatch(field, value string) bool {
+	return field == "*" || field == value
+}
+
+// helper function to split line of pgpass-style file into its fields
+// the colon and backslash characters can be escaped with backslash
+func splitPassLine(line string) []string {
+	var fields []string
+	var field strings.Builder
+	escape := false
+	for _, r := range line {
+		if escape {
+			field.WriteRune(r)
+			escape = false
+			continue
+		}
+		if r == '\\' {
+			escape = true
+		} else if r == ':' {
+			fields = append(fields, field.String())
+			field.Reset()
+		} else {
+			field.WriteRune(r)
+		}
+	}
+	return append(fields, field.String())
+}
+
+// helper function to look-up password in pgpass-style file
+func (c *Credentials) passFilePassword(fname string) (string, bool) {
+	info, err := os.Stat(fname)
+	if err != nil {
+		return "", false
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		log.Printf("WARNING: password file %s has group or world access; permissions should be u=rw (0600) or less", fname)
+		return "", false
+	}
+	file, err := os.Open(fname)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		arr := splitPassLine(line)
+		if len(arr) != 5 {
+			continue
+		}
+		if passFieldMatch(arr[0], c.Host) &&
+			passFieldMatch(arr[1], c.Port) &&
+			passFieldMatch(arr[2], c.Database) &&
+			passFieldMatch(arr[3], c.User) {
+			return arr[4], true
+		}
+	}
+	return "", false
+}
+
+// helper function to obtain password from output of given command
+func commandPassword(command string) (string, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", Error(err, SessionErrorCode, "password_command failure", "commandPassword")
+	}
+	// use first line of the output similar to pass(1) conventions
+	pwd := strings.SplitN(string(out), "\n", 2)[0]
+	return strings.TrimRight(pwd, "\r"), nil
+}
+
+// helper function to read password from terminal with disabled echo
+func readPassword(prompt string) (string, error) {
+	if atomic.LoadInt32(&keyboardMode) == 1 {
+		// keyboard handler owns the terminal, ask it to read secret input
+		fmt.Print(prompt)
+		atomic.StoreInt32(&secretMode, 1)
+		pwd := <-SECRET
+		fmt.Println()
+		return pwd, nil
+	}
+	fmt.Print(prompt)
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// helper function to add password to DB uri which has user name but no password,
+// explicitly given empty password, e.g. user:@host, is kept
+func uriWithPassword(dburi string) (string, error) {
+	dsn, err := parseURI(dburi)
+	if err != nil {
+		return dburi, err
+	}
+	if dsn.User == "" || dsn.PassSet {
+		return dburi, nil
+	}
+	dialect, err := dialectFor(dsn.Scheme)
+	if err != nil {
+		return dburi, err
+	}
+	cred := Credentials{
+		Dialect:  dialect,
+		Host:     dsn.Host,
+		Port:     dsn.Port,
+		Database: dsn.Database,
+		User:     dsn.User,
+	}
+	dsn.Password, err = cred.Password()
+	if err != nil || dsn.Password == "" {
+		return dburi, err
+	}
+	return dsn.URI(), nil
+}
+
+// helper function to remove password from DB uri, e.g. to keep it in memory
+func uriWithoutPassword(dburi string) string {
+	dsn, err := parseURI(dburi)
+	if err != nil {
+		return maskURI(dburi)
+	}
+	if dsn.Password == "" {
+		return dburi
+	}
+	dsn.Password, dsn.PassSet = "", false
+	return dsn.URI()
+}
+
+// helper function to expand tilde in given path
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if udir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(udir, path[2:])
+		}
+	}
+	return path
+}
+
+// uriPassword represents password part of DB uri, e.g. scheme://user:password@ or scheme://user/password@,
+// the password ends at the last @ before the host as url.Parse does, i.e. it may contain @ and :
+var uriPassword = regexp.MustCompile(`([A-Za-z0-9]+://[^:/@\s]+[:/])([^/\s]*)@`)
+
+// dsnPassword represents password part of keyword based DSN, e.g. password=xyz
+var dsnPassword = regexp.MustCompile(`(password=)(\S*)`)
+
+// MaskedPassword represents string used to hide passwords
+const MaskedPassword = "****"
+
+// helper function to mask password(s) of DB uri in given string
+func maskURI(uri string) string {
+	uri = uriPassword.ReplaceAllString(uri, "${1}"+MaskedPassword+"@")
+	return dsnPassword.ReplaceAllString(uri, "${1}"+MaskedPassword)
+}
@@ -0,0 +1,274 @@
+package main
+
+// cursor module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// When pager is set, e.g. set pager=20, results of SELECT statements are
+// shown page by page. The DB cursor is kept open and records are fetched only
+// when they are requested by next, page N or last commands. Fetched records
+// of last CursorPages pages are kept in memory such that prev and page N
+// commands do not execute the query again. The open cursor holds transaction
+// of its connection, therefore statements other than SELECT are refused until
+// all records are fetched or cursor is closed.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PAGER represents number of records per page, if it is positive
+// SELECT results are browsed via DB cursor
+var PAGER = 0
+
+// CURSOR represents DB cursor of last SELECT statement of current connection
+var CURSOR *Cursor
+
+// CursorPages represents maximum number of pages kept in memory by DB cursor
+var CursorPages = 100
+
+// Cursor represents open result set of SELECT statement
+type Cursor struct {
+	Query string // SQL statement
+	Page  int    // current page number, starts from 1
+	Size  int    // number of records per page
+
+	tx      *sql.Tx
+	rows    *sql.Rows
+	columns []Column        // columns metadata
+	values  []interface{}   // scan buffers
+	ptrs    []interface{}   // pointers to scan buffers
+	records [][]interface{} // window of fetched records, i.e. scanned values
+	offset  int             // number of fetched records dropped from the window
+	eof     bool            // all records are fetched
+	closed  bool            // cursor is closed
+}
+
+// helper function to execute SELECT statement and open DB cursor for it
+func openCursor(stm string, size int, args ...interface{}) (*Cursor, error) {
+	stm = cleanStatement(stm)
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, Error(err, TransactionErrorCode, "", "openCursor")
+	}
+	rows, err := tx.Query(stm, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, Error(err, QueryErrorCode, "unable to query statement", "openCursor")
+	}
+	columns, err := queryColumns(rows)
+	if err != nil {
+		rows.Close()
+		tx.Rollback()
+		return nil, Error(err, QueryErrorCode, "unable to get columns", "openCursor")
+	}
+	c := &Cursor{
+		Query:   stm,
+		Size:    size,
+		tx:      tx,
+		rows:    rows,
+		columns: columns,
+		values:  make([]interface{}, len(columns)),
+		ptrs:    make([]interface{}, len(columns)),
+	}
+	for i := range c.values {
+		c.ptrs[i] = &c.values[i]
+	}
+	return c, nil
+}
+
+// helper function to return number of fetched records
+func (c *Cursor) count() int {
+	return c.offset + len(c.records)
+}
+
+// helper function to fetch records until cursor fetched n records, negative
+// n fetches all records, the cursor is closed on error
+func (c *Cursor) fetch(n int) error {
+	for !c.eof && (n < 0 || c.count() < n) {
+		if !c.rows.Next() {
+			err := c.rows.Err()
+			// release DB resources as soon as all records are fetched
+			c.release()
+			if err != nil {
+				c.Close()
+				return Error(err, RowsScanErrorCode, "", "fetch")
+			}
+			return nil
+		}
+		if err := c.rows.Scan(c.ptrs...); err != nil {
+			c.Close()
+			return Error(err, RowsScanErrorCode, "", "fetch")
+		}
+		c.records = append(c.records, append([]interface{}(nil), c.values...))
+		if CursorPages > 0 && len(c.records) >= (CursorPages+1)*c.Size {
+			// drop the oldest page from the window
+			c.records = append([][]interface{}(nil), c.records[c.Size:]...)
+			c.offset += c.Size
+		}
+	}
+	return nil
+}
+
+// helper function to close DB cursor and its transaction
+func (c *Cursor) release() {
+	c.eof = true
+	if c.rows != nil {
+		c.rows.Close()
+		c.rows = nil
+	}
+	if c.tx != nil {
+		c.tx.Rollback()
+		c.tx = nil
+	}
+}
+
+// Close closes cursor and drops its records
+func (c *Cursor) Close() {
+	c.release()
+	c.records = nil
+	c.closed = true
+}
+
+// helper function to check if cursor holds transaction of its connection
+func (c *Cursor) active() bool {
+	return c != nil && c.tx != nil
+}
+
+// helper function to show given page of cursor records
+func (c *Cursor) show(page int) error {
+	if page < 1 {
+		return errors.New("no previous page")
+	}
+	if err := c.fetch(page * c.Size); err != nil {
+		return err
+	}
+	first := (page - 1) * c.Size
+	if first >= c.count() && page > 1 {
+		return fmt.Errorf("no more records, last page is %d", c.lastPage())
+	}
+	if first < c.offset {
+		return fmt.Errorf("page %d is no longer kept in memory, only last %d pages are kept, please run the query again", page, CursorPages)
+	}
+	last := first + c.Size
+	if last > c.count() {
+		last = c.count()
+	}
+	c.Page = page
+
+	printer := newRecordPrinter(os.Stdout, c.Query, c.columns, c.Size)
+	for _, values := range c.records[first-c.offset : last-c.offset] {
+		printer.PrintValues(values)
+	}
+	printer.Flush()
+
+	if c.eof && c.count() == 0 {
+		fmt.Println("no records")
+	} else if c.eof {
+		fmt.Printf("page %d of %d, records %d-%d of %d\n", page, c.lastPage(), first+1, last, c.count())
+	} else {
+		fmt.Printf("page %d, records %d-%d, use next for more records\n", page, first+1, last)
+	}
+	return nil
+}
+
+// helper function to return number of last page of fetched records
+func (c *Cursor) lastPage() int {
+	if c.count() == 0 {
+		return 1
+	}
+	return (c.count() + c.Size - 1) / c.Size
+}
+
+// helper function to execute SELECT statement and show first page of its records
+func browse(stm string, args ...interface{}) error {
+	closeCursor()
+	c, err := openCursor(stm, PAGER, args...)
+	if err != nil {
+		return err
+	}
+	CURSOR = c
+	if err := c.show(1); err != nil {
+		closeCursor()
+		return err
+	}
+	return nil
+}
+
+// helper function to close DB cursor of current connection
+func closeCursor() {
+	if CURSOR != nil {
+		CURSOR.Close()
+		CURSOR = nil
+	}
+}
+
+// helper function to check if statement should be browsed via DB cursor
+func browseStatement(stm string) bool {
+	if PAGER <= 0 {
+		return false
+	}
+	stm = strings.ToLower(strings.TrimSpace(stm))
+	return strings.HasPrefix(stm, "select") || strings.HasPrefix(stm, "with")
+}
+
+// helper function to check if statement can be executed while DB cursor of
+// current connection is open, i.e. only SELECT statements do not interfere
+// with transaction held by the cursor
+func cursorAllows(stm string) error {
+	if !CURSOR.active() || browseStatement(stm) {
+		return nil
+	}
+	lstm := strings.ToLower(strings.TrimSpace(stm))
+	if strings.HasPrefix(lstm, "select") || strings.HasPrefix(lstm, "with") {
+		return nil
+	}
+	return errors.New("DB cursor of previous SELECT statement is open, please use last command to fetch all its records or set pager=0 to close it")
+}
+
+// helper function to handle next, prev, page N and last commands
+func pageCommand(command string) error {
+	if CURSOR == nil || CURSOR.closed {
+		return errors.New("no open cursor, please use set pager=N and run SELECT statement")
+	}
+	arr := strings.Fields(command)
+	switch arr[0] {
+	case "next":
+		return CURSOR.show(CURSOR.Page + 1)
+	case "prev":
+		return CURSOR.show(CURSOR.Page - 1)
+	case "last":
+		if err := CURSOR.fetch(-1); err != nil {
+			return err
+		}
+		return CURSOR.show(CURSOR.lastPage())
+	case "page":
+		if len(arr) != 2 {
+			return errors.New("usage: page <number>")
+		}
+		page, err := strconv.Atoi(arr[1])
+		if err != nil {
+			return fmt.Errorf("wrong page number '%s'", arr[1])
+		}
+		return CURSOR.show(page)
+	}
+	return fmt.Errorf("unknown page command '%s'", command)
+}
+
+// helper function to check if given input is page command
+func isPageCommand(command string) bool {
+	arr := strings.Fields(command)
+	if len(arr) == 0 {
+		return false
+	}
+	switch arr[0] {
+	case "next", "prev", "last":
+		return len(arr) == 1
+	case "page":
+		return true
+	}
+	return false
+}
@@ -11,10 +11,13 @@ package main
 //   _ "github.com/go-sql-driver/mysql"
 // SQLite driver:
 //  _ "github.com/mattn/go-sqlite3"
+// DuckDB driver:
+//  _ "github.com/marcboeker/go-duckdb"
 //
 // For full list of supported databases in Go please refer to
 // https://github.com/golang/go/wiki/SQLDrivers
 // All of them can be added in this package but so far we concentrate on traditional RDBMS
+// Each driver is imported by its dialect, see dialect_<name>.go files
 
 import (
 	"database/sql"
@@ -24,15 +27,12 @@ import (
 	"io"
 	"log"
 	"os"
+	"reflect"
 	"sort"
 	"strings"
-	"text/tabwriter"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/gookit/color"
-	_ "github.com/lib/pq"
-	_ "github.com/mattn/go-oci8"
-	_ "github.com/mattn/go-sqlite3"
 )
 
 // DB represents sql DB pointer
@@ -44,6 +44,9 @@ var TX *sql.Tx
 // DBTYPE represents DBS DB type, e.g. ORACLE or SQLite
 var DBTYPE string
 
+// DIALECT represents dialect of current DB connection
+var DIALECT Dialect
+
 // INDEX represents index value to use when printing DB records
 // by default we use first record
 var INDEX = 0
@@ -62,27 +65,27 @@ var DBSQL Record
 var DBOWNER string
 
 // helper function to initialize DB access
-func dbInit(dburi string) (*sql.DB, error) {
-	if strings.HasPrefix(dburi, "sqlite") {
-		DBTYPE = "sqlite3"
-	} else if strings.HasPrefix(dburi, "mysql") {
-		DBTYPE = "mysql"
-	} else if strings.HasPrefix(dburi, "postgres") {
-		DBTYPE = "postgres"
-	} else {
-		DBTYPE = "oci8"
+func dbInit(dburi string) (*sql.DB, Dialect, error) {
+	dialect, err := dialectForURI(dburi)
+	if err != nil {
+		return nil, nil, err
+	}
+	dsn, err := parseDBUri(dialect, dburi)
+	if err != nil {
+		return nil, dialect, err
 	}
-	db, dberr := sql.Open(DBTYPE, parseDBUri(DBTYPE, dburi))
+	db, dberr := sql.Open(dialect.Driver(), dsn)
 	if dberr != nil {
-		log.Printf("unable to open %s, error %v", DBTYPE, dburi)
-		return nil, dberr
+		log.Printf("unable to open %s, error %v", maskURI(dburi), dberr)
+		return nil, dialect, dberr
 	}
 	dberr = db.Ping()
 	if dberr != nil {
 		log.Println("DB ping error", dberr)
-		return nil, dberr
+		db.Close()
+		return nil, dialect, dberr
 	}
-	return db, nil
+	return db, dialect, nil
 }
 
 // cleanStatement cleans the given SQL statement to remove empty strings, etc.
@@ -99,9 +102,66 @@ func cleanStatement(stm string) string {
 	return stm
 }
 
+// helper function to split text into statements separated by semicolons,
+// semicolons within quotes are kept and comments are removed
+func splitStatements(text string) []string {
+	var stms []string
+	var sb strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			// copy quoted text as is, doubled quote is an escaped one
+			sb.WriteRune(r)
+			for i++; i < len(runes); i++ {
+				sb.WriteRune(runes[i])
+				if runes[i] == r {
+					if i+1 < len(runes) && runes[i+1] == r {
+						i++
+						sb.WriteRune(r)
+						continue
+					}
+					break
+				}
+			}
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			sb.WriteRune('\n')
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			// skip closing slash of the comment
+			i++
+			sb.WriteRune(' ')
+		case r == ';':
+			if stm := strings.TrimSpace(sb.String()); stm != "" {
+				stms = append(stms, stm)
+			}
+			sb.Reset()
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if stm := strings.TrimSpace(sb.String()); stm != "" {
+		stms = append(stms, stm)
+	}
+	return stms
+}
+
 // helper function to execute different SQL statements
 func executeSQL(stm string, args ...interface{}) error {
 	var err error
+	if DB == nil {
+		return errors.New("no active DB connection, please use connect command")
+	}
+	if err := cursorAllows(stm); err != nil {
+		return err
+	}
 	if strings.HasPrefix(strings.ToLower(stm), "begin") {
 		TX, err = DB.Begin()
 		if err != nil {
@@ -129,14 +189,35 @@ func executeSQL(stm string, args ...interface{}) error {
 	} else if strings.HasPrefix(strings.ToLower(stm), "insert") ||
 		strings.HasPrefix(strings.ToLower(stm), "delete") {
 		if TX != nil {
-			_, err = TX.Exec(stm, args...)
+			start := time.Now()
+			res, err := TX.Exec(stm, args...)
 			if err != nil {
 				log.Println(stm, "error", err)
 				return errors.New("unable to execute statement")
 			}
+			printResult(res, start)
+		}
+	} else if browseStatement(stm) {
+		err = browse(stm, args...)
+		if err != nil {
+			log.Println("db error:", err)
+			return err
 		}
 	} else {
-		err = execute(stm, args...)
+		start, end := INDEX, LIMIT
+		if query, ok := pushdownStatement(stm); ok {
+			// DB returns only rows within index-limit range
+			stm = query
+			start, end = 0, LIMIT-INDEX
+			if LIMIT <= 0 {
+				end = 0
+			}
+			if SHOWSQL {
+				// use stderr to keep output of machine formats intact
+				fmt.Fprintln(os.Stderr, stm)
+			}
+		}
+		err = executeRange(stm, start, end, args...)
 		if err != nil {
 			log.Println("db error:", err)
 			return err
@@ -145,153 +226,435 @@ func executeSQL(stm string, args ...interface{}) error {
 	return err
 }
 
-// generic API to execute given statement
+// generic API to execute given statement and print records within index-limit range
+func execute(stm string, args ...interface{}) error {
+	return executeRange(stm, INDEX, LIMIT, args...)
+}
+
+// helper function to execute given statement and print records with
+// start <= row number < end, where end <= 0 means no limit
 // ideas are taken from
 // http://stackoverflow.com/questions/17845619/how-to-call-the-scan-variadic-function-in-golang-using-reflection
+func executeRange(stm string, start, end int, args ...interface{}) error {
+	return executeSteps(nil, stm, start, end, args...)
+}
+
+// helper function to execute given steps and statement within single
+// transaction and print records of the statement with start <= row number < end,
+// e.g. ORACLE explain plan is stored by one statement and read by another one
+// which should use the same DB session
+//
 //gocyclo:ignore
-func execute(stm string, args ...interface{}) error {
+func executeSteps(steps []string, stm string, start, end int, args ...interface{}) error {
 	stm = cleanStatement(stm)
 
 	// execute transaction
+	began := time.Now()
 	tx, err := DB.Begin()
 	if err != nil {
 		return Error(err, TransactionErrorCode, "", "execute")
 	}
 	defer tx.Rollback()
+	for _, step := range steps {
+		if _, err := tx.Exec(step); err != nil {
+			return Error(err, QueryErrorCode, fmt.Sprintf("unable to execute statement: %v", step), "execute")
+		}
+	}
 	rows, err := tx.Query(stm, args...)
 	if err != nil {
 		msg := fmt.Sprintf("unable to query statement: %v", stm)
-		fmt.Println()
+		if !jsonFormat() {
+			fmt.Println()
+		}
 		log.Println(msg)
 		return Error(err, QueryErrorCode, "", "execute")
 	}
 	defer rows.Close()
 
-	// initialize tabwriter
-	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, MinWidth, TabWidth, Padding, ' ', 0)
-	defer w.Flush()
-
 	// extract columns from Rows object and create values & valuesPtrs to retrieve results
-	columns, _ := rows.Columns()
-	var cols []string
+	// scan buffers and record are reused for all rows to keep memory usage flat
+	columns, err := queryColumns(rows)
+	if err != nil {
+		return Error(err, QueryErrorCode, "unable to get columns", "execute")
+	}
 	count := len(columns)
 	values := make([]interface{}, count)
 	valuePtrs := make([]interface{}, count)
-	rowCount := 0
-	if DBFORMAT == "rows" {
-		fmt.Println("")
+	for i := range columns {
+		valuePtrs[i] = &values[i]
 	}
+
+	printer := newRecordPrinter(os.Stdout, stm, columns, SampleSize)
+	printer.start = began
+	rowCount := 0
 	for rows.Next() {
-		if rowCount == 0 {
-			// initialize value pointers
-			for i := range columns {
-				valuePtrs[i] = &values[i]
-			}
+		if end > 0 && rowCount >= end {
+			break
+		}
+		if rowCount < start {
+			rowCount += 1
+			continue
 		}
 		err := rows.Scan(valuePtrs...)
 		if err != nil {
-			return Error(err, RowsScanErrorCode, "", "execute")
+			return printer.Fail(Error(err, RowsScanErrorCode, "", "execute"))
 		}
-		// store results into generic record (a dict)
-		rec := make(Record)
-		for i, col := range columns {
-			if len(cols) != len(columns) {
-				cols = append(cols, strings.ToLower(col))
-			}
-			vvv := values[i]
-			switch val := vvv.(type) {
-			case *sql.NullString:
-				v, e := val.Value()
-				if e == nil {
-					rec[cols[i]] = v
-				}
-			case *sql.NullInt64:
-				v, e := val.Value()
-				if e == nil {
-					rec[cols[i]] = v
-				}
-			case *sql.NullFloat64:
-				v, e := val.Value()
-				if e == nil {
-					rec[cols[i]] = v
-				}
-			case *sql.NullBool:
-				v, e := val.Value()
-				if e == nil {
-					rec[cols[i]] = v
-				}
-			default:
-				rec[cols[i]] = val
-			}
-		}
-		if LIMIT > 0 && rowCount > LIMIT {
-			break
-		}
-		printRecord(w, rec, rowCount)
+		printer.PrintValues(values)
 		rowCount += 1
 	}
-	// add additional print at the end
-	if DBFORMAT == "rows" {
-		fmt.Fprintf(w, "\n")
-	} else {
-		fmt.Println()
-	}
 	if err = rows.Err(); err != nil {
-		return Error(err, RowsScanErrorCode, "", "execute")
+		return printer.Fail(Error(err, RowsScanErrorCode, "", "execute"))
 	}
+	printer.Flush()
 	return nil
 }
 
-// helper function to print DB record
-func printRecord(w io.Writer, rec Record, rowCount int) {
-	// do not print if we are out of range
-	if rowCount < INDEX || (LIMIT > 0 && rowCount > LIMIT) {
-		return
+// helper function to store scanned values into given record
+func fillRecord(rec Record, columns []Column, values []interface{}) {
+	for i, col := range columns {
+		if col.Name == RowNumColumn {
+			continue
+		}
+		rec[col.Name] = columnValue(values[i], col)
 	}
-	var maxKeyLength int
+}
+
+// helper function to return sorted record keys for given columns
+func recordKeys(columns []Column) []string {
 	var keys []string
-	for key := range rec {
-		if len(key) > maxKeyLength {
-			maxKeyLength = len(key)
+	for _, col := range columns {
+		if col.Name != RowNumColumn {
+			keys = append(keys, col.Name)
 		}
-		keys = append(keys, key)
 	}
 	sort.Strings(keys)
-	if DBFORMAT == "pairs" {
-		fmt.Println("")
-		for _, key := range keys {
-			val, _ := rec[key]
-			var pad string
-			for i := 0; i < maxKeyLength-len(key); i++ {
-				pad += " "
+	return keys
+}
+
+// recordPrinter prints DB records in current DB format
+type recordPrinter struct {
+	out     io.Writer     // output writer
+	columns []Column      // columns metadata in order of record keys
+	keys    []string      // sorted record keys
+	rows    *rowsPrinter  // printer of rows format
+	vals    []string      // buffer of formatted values
+	count   int           // number of printed records
+	start   time.Time     // start time of the query
+	table   string        // table name of insert format
+	query   []Column      // columns metadata in order of query
+	index   []int         // query column indexes of record keys
+	raw     []interface{} // buffer of raw values in order of record keys
+	rec     Record        // buffer of printed record
+}
+
+// helper function to create new record printer for given query columns,
+// size is number of records to sample in rows format
+func newRecordPrinter(out io.Writer, query string, columns []Column, size int) *recordPrinter {
+	p := &recordPrinter{out: out, keys: recordKeys(columns), start: time.Now(), query: columns}
+	byName := make(map[string]int)
+	for idx, col := range columns {
+		byName[col.Name] = idx
+	}
+	for _, key := range p.keys {
+		p.index = append(p.index, byName[key])
+		p.columns = append(p.columns, columns[byName[key]])
+	}
+	p.vals = make([]string, len(p.keys))
+	p.raw = make([]interface{}, len(p.keys))
+	p.rec = make(Record, len(p.keys))
+	if DBFORMAT == "rows" {
+		fmt.Fprintln(out, "")
+		p.rows = newRowsPrinter(out, p.keys, size)
+		if SHOWTYPES {
+			var types []string
+			for _, col := range p.columns {
+				types = append(types, col.Describe())
 			}
-			if COLOR {
-				fmt.Printf("%s%s: %v\n", color.Notice.Sprintf(key), pad, val)
-			} else {
-				fmt.Printf("%s%s: %v\n", key, pad, val)
+			p.rows.SetTypes(types)
+		}
+	} else if DBFORMAT == "json" && SHOWTYPES {
+		data, err := json.Marshal(map[string]interface{}{"schema": p.columns})
+		if err == nil {
+			fmt.Fprintln(out, string(data))
+		}
+	} else if DBFORMAT == "json-array" {
+		fmt.Fprint(out, "[")
+	} else if DBFORMAT == "json-envelope" {
+		types := make([]string, 0, len(p.columns))
+		for _, col := range p.columns {
+			types = append(types, col.Type)
+		}
+		keys, _ := json.Marshal(p.keys)
+		ctypes, _ := json.Marshal(types)
+		fmt.Fprintf(out, `{"columns":%s,"types":%s,"rows":[`, keys, ctypes)
+	} else if DBFORMAT == "xml" {
+		fmt.Fprintln(out, `<?xml version="1.0" encoding="UTF-8"?>`)
+		fmt.Fprintf(out, "<%s>\n", xmlName(XMLROOT))
+	} else if DBFORMAT == "insert" {
+		p.table = insertTable(query)
+		if p.table == UnknownTable {
+			fmt.Fprintln(out, "-- unable to guess table name, please use set table=<name>")
+		}
+	}
+	return p
+}
+
+// PrintValues prints DB record of given raw values scanned in order of query
+// columns, the insert format builds SQL literals from raw values
+func (p *recordPrinter) PrintValues(values []interface{}) {
+	if DBFORMAT == "insert" {
+		for i, idx := range p.index {
+			p.raw[i] = values[idx]
+		}
+		fmt.Fprintln(p.out, insertRecord(p.raw, p.columns, p.table))
+		return
+	}
+	fillRecord(p.rec, p.query, values)
+	p.Print(p.rec)
+}
+
+// Print prints given DB record
+func (p *recordPrinter) Print(rec Record) {
+	if p.rows != nil {
+		for i, key := range p.keys {
+			p.vals[i] = formatValue(rec[key])
+		}
+		p.rows.Print(p.vals)
+		return
+	}
+	switch DBFORMAT {
+	case "json", "ndjson":
+		data, err := json.Marshal(jsonValue(rec))
+		if err == nil {
+			fmt.Fprintln(p.out, string(data))
+		}
+		return
+	case "json-array":
+		data, err := json.Marshal(jsonValue(rec))
+		if err == nil {
+			if p.count > 0 {
+				fmt.Fprint(p.out, ",")
 			}
+			fmt.Fprintf(p.out, "\n%s", data)
+			p.count++
 		}
 		return
-	} else if DBFORMAT == "json" {
-		data, err := json.Marshal(rec)
+	case "yaml":
+		data, err := yamlRecord(rec)
+		if err == nil {
+			fmt.Fprint(p.out, data)
+			p.count++
+		}
+		return
+	case "xml":
+		fmt.Fprintln(p.out, xmlRecord(rec, p.keys))
+		return
+	case "json-envelope":
+		row := make([]interface{}, len(p.keys))
+		for i, key := range p.keys {
+			row[i] = jsonValue(rec[key])
+		}
+		data, err := json.Marshal(row)
 		if err == nil {
-			fmt.Println(string(data))
+			if p.count > 0 {
+				fmt.Fprint(p.out, ",")
+			}
+			fmt.Fprintf(p.out, "\n%s", data)
+			p.count++
+		}
+		return
+	}
+
+	// pairs format
+	labels := p.keys
+	if SHOWTYPES {
+		labels = make([]string, len(p.keys))
+		for i, col := range p.columns {
+			labels[i] = fmt.Sprintf("%s [%s]", col.Name, col.Describe())
+		}
+	}
+	var maxKeyLength int
+	for _, label := range labels {
+		if w := textWidth(label); w > maxKeyLength {
+			maxKeyLength = w
+		}
+	}
+	fmt.Fprintln(p.out, "")
+	for i, key := range p.keys {
+		val := rec[key]
+		pad := strings.Repeat(" ", maxKeyLength-textWidth(labels[i]))
+		sval := formatValue(val)
+		if doc, ok := val.(JSONText); ok {
+			// JSON documents are indented and aligned with other values
+			sval = doc.Indent(strings.Repeat(" ", maxKeyLength+2))
+		}
+		if COLOR {
+			fmt.Fprintf(p.out, "%s%s: %s\n", color.Notice.Sprintf(labels[i]), pad, sval)
+		} else {
+			fmt.Fprintf(p.out, "%s%s: %s\n", labels[i], pad, sval)
+		}
+	}
+}
+
+// Flush prints buffered records
+func (p *recordPrinter) Flush() {
+	switch DBFORMAT {
+	case "ndjson", "insert":
+		return
+	case "yaml":
+		if p.count == 0 {
+			fmt.Fprintln(p.out, "[]")
+		}
+		return
+	case "xml":
+		fmt.Fprintf(p.out, "</%s>\n", xmlName(XMLROOT))
+		return
+	case "json-array":
+		fmt.Fprintln(p.out, "\n]")
+		return
+	case "json-envelope":
+		// statements which return records do not affect rows
+		fmt.Fprintf(p.out, "\n],\"elapsed_ms\":%d,\"rows_affected\":null}\n", time.Since(p.start).Milliseconds())
+		return
+	}
+	if p.rows != nil {
+		p.rows.Flush()
+	}
+	// add additional print at the end
+	fmt.Fprintln(p.out)
+}
+
+// Fail finishes output of records on given error and returns the error. In
+// json-array and json-envelope formats the error becomes part of the printed
+// document, i.e. last element of the array or error field of the envelope, such
+// that output remains single JSON document, and returned error is not printed again
+func (p *recordPrinter) Fail(err error) error {
+	if DBFORMAT != "json-array" && DBFORMAT != "json-envelope" {
+		p.Flush()
+		return err
+	}
+	data, e := jsonError(err)
+	if e != nil {
+		p.Flush()
+		return err
+	}
+	if DBFORMAT == "json-array" {
+		if p.count > 0 {
+			fmt.Fprint(p.out, ",")
 		}
+		fmt.Fprintf(p.out, "\n%s\n]\n", data)
+	} else {
+		fmt.Fprintf(p.out, "\n],\"elapsed_ms\":%d,\"rows_affected\":null,%s}\n",
+			time.Since(p.start).Milliseconds(), data[1:len(data)-1])
+	}
+	return &reportedError{err: err}
+}
+
+// helper function to print result of statement which does not return records,
+// it is only printed in json-envelope format
+func printResult(res sql.Result, start time.Time) {
+	if DBFORMAT != "json-envelope" {
 		return
 	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		affected = 0
+	}
+	fmt.Printf("{\"columns\":[],\"types\":[],\"rows\":[],\"elapsed_ms\":%d,\"rows_affected\":%d}\n",
+		time.Since(start).Milliseconds(), affected)
+}
+
+// helper function to format DB value, it renders nested values
+// such as DuckDB LIST, STRUCT and MAP types in SQL like notation, e.g.
+// [1, 2, 3], {'a': 1, 'b': x} and {k1=v1, k2=v2}
+func formatValue(val interface{}) string {
+	if val == nil {
+		return NULLMARKER
+	}
+	if s, ok := val.(fmt.Stringer); ok {
+		return s.String()
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if b, ok := val.([]byte); ok {
+			return Binary(b).String()
+		}
+		var items []string
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, formatNested(rv.Index(i).Interface()))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Map:
+		var items []string
+		for _, key := range sortedKeys(rv) {
+			item := rv.MapIndex(key).Interface()
+			if key.Kind() == reflect.String {
+				// STRUCT type with named fields
+				items = append(items, fmt.Sprintf("'%v': %s", key.Interface(), formatNested(item)))
+			} else {
+				// MAP type with arbitrary keys
+				items = append(items, fmt.Sprintf("%s=%s", formatNested(key.Interface()), formatNested(item)))
+			}
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+	return fmt.Sprintf("%v", val)
+}
 
-	// print column names if necessary
-	if rowCount == 0 {
-		fmt.Fprintf(w, strings.Join(keys, "\t"))
-		fmt.Fprintf(w, "\n")
+// helper function to format value nested in LIST, STRUCT or MAP types
+func formatNested(val interface{}) string {
+	if s, ok := val.(string); ok {
+		return fmt.Sprintf("'%s'", s)
 	}
+	return formatValue(val)
+}
 
-	// print row values
-	var vals []string
-	for _, key := range keys {
-		val, _ := rec[key]
-		vals = append(vals, fmt.Sprintf("%v", val))
+// helper function to return map keys sorted by their string representation
+func sortedKeys(rv reflect.Value) []reflect.Value {
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprintf("%v", keys[i].Interface()) < fmt.Sprintf("%v", keys[j].Interface())
+	})
+	return keys
+}
+
+// helper function to convert DB value to JSON compatible one, e.g.
+// DuckDB MAP type with non-string keys is converted to list of key/value objects
+func jsonValue(val interface{}) interface{} {
+	if val == nil {
+		return nil
+	}
+	if _, ok := val.(json.Marshaler); ok {
+		return val
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if b, ok := val.([]byte); ok {
+			return Binary(b)
+		}
+		items := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, jsonValue(rv.Index(i).Interface()))
+		}
+		return items
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			obj := make(map[string]interface{}, rv.Len())
+			for _, key := range rv.MapKeys() {
+				obj[key.String()] = jsonValue(rv.MapIndex(key).Interface())
+			}
+			return obj
+		}
+		var items []interface{}
+		for _, key := range sortedKeys(rv) {
+			items = append(items, map[string]interface{}{
+				"key":   jsonValue(key.Interface()),
+				"value": jsonValue(rv.MapIndex(key).Interface()),
+			})
+		}
+		return items
 	}
-	fmt.Fprintf(w, strings.Join(vals, "\t")+"\n")
+	return val
 }
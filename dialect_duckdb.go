@@ -0,0 +1,116 @@
+//go:build duckdb || (cgo && !(oracle || sqlite_cgo || sqlite_pure || mysql || postgres || duckdb))
+
+package main
+
+// DuckDB dialect
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/marcboeker/go-duckdb"
+)
+
+func init() {
+	RegisterDialect(duckdbDialect{})
+}
+
+// duckdbDialect represents DuckDB backend
+type duckdbDialect struct {
+	baseDialect
+}
+
+// Name implements Dialect interface
+func (duckdbDialect) Name() string { return "duckdb" }
+
+// Driver implements Dialect interface
+func (duckdbDialect) Driver() string { return "duckdb" }
+
+// Schemes implements Dialect interface
+func (duckdbDialect) Schemes() []string { return []string{"duckdb"} }
+
+// Example implements Dialect interface
+func (duckdbDialect) Example() string { return "duckdb:///path/file.db or duckdb:// (in-memory)" }
+
+// FileBased implements Dialect interface
+func (duckdbDialect) FileBased() bool { return true }
+
+// TablesQuery implements Dialect interface
+func (duckdbDialect) TablesQuery() string {
+	return "SELECT table_schema, table_name, table_type FROM information_schema.tables ORDER BY table_schema, table_name"
+}
+
+// DescribeQuery implements Dialect interface
+func (duckdbDialect) DescribeQuery() string {
+	return "SELECT ordinal_position AS position, column_name, data_type, is_nullable, column_default FROM information_schema.columns WHERE table_name = ? ORDER BY ordinal_position"
+}
+
+// QuoteBinary implements Dialect interface, DuckDB uses escaped bytes in BLOB literals
+func (duckdbDialect) QuoteBinary(val []byte) string {
+	var sb strings.Builder
+	for _, b := range val {
+		fmt.Fprintf(&sb, "\\x%02X", b)
+	}
+	return fmt.Sprintf("'%s'::BLOB", sb.String())
+}
+
+// TypeCategory implements Dialect interface
+func (duckdbDialect) TypeCategory(typeName string) string {
+	name := strings.ToUpper(strings.TrimSpace(typeName))
+	switch {
+	case strings.HasSuffix(name, "[]") || strings.HasPrefix(name, "LIST"):
+		return TypeList
+	case strings.HasPrefix(name, "STRUCT"):
+		return TypeStruct
+	case strings.HasPrefix(name, "MAP"):
+		return TypeMap
+	}
+	return typeCategory(name)
+}
+
+// ConvertValue converts go-duckdb specific values, i.e. DECIMAL, UUID and INTERVAL
+func (duckdbDialect) ConvertValue(val interface{}, col Column) interface{} {
+	switch v := val.(type) {
+	case duckdb.Decimal:
+		return decimalString(v.Value, int(v.Scale))
+	case duckdb.Interval:
+		return fmt.Sprintf("%d months %d days %s", v.Months, v.Days, time.Duration(v.Micros)*time.Microsecond)
+	case []byte:
+		if strings.ToUpper(col.Type) == "UUID" && len(v) == 16 {
+			return fmt.Sprintf("%x-%x-%x-%x-%x", v[0:4], v[4:6], v[6:8], v[8:10], v[10:16])
+		}
+	}
+	return val
+}
+
+// DSN implements Dialect interface, it builds go-duckdb DSN
+func (duckdbDialect) DSN(d *DSN) (string, error) {
+	path := d.Database
+	if path == ":memory:" {
+		// empty path represents in-memory DuckDB database
+		path = ""
+	}
+	opts := url.Values{}
+	for _, key := range d.optionKeys() {
+		val := d.Options.Get(key)
+		switch key {
+		case "mode":
+			if val == "ro" {
+				opts.Set("access_mode", "READ_ONLY")
+			} else if val == "rw" {
+				opts.Set("access_mode", "READ_WRITE")
+			} else {
+				return "", fmt.Errorf("unsupported duckdb mode=%s, use ro or rw", val)
+			}
+		default:
+			opts.Set(key, val)
+		}
+	}
+	if len(opts) > 0 {
+		path += "?" + opts.Encode()
+	}
+	return path, nil
+}
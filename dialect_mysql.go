@@ -0,0 +1,203 @@
+//go:build mysql || !(oracle || sqlite_cgo || sqlite_pure || mysql || postgres || duckdb)
+
+package main
+
+// MySQL dialect
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	RegisterDialect(mysqlDialect{})
+}
+
+// mysqlDialect represents MySQL backend
+type mysqlDialect struct {
+	baseDialect
+}
+
+// Name implements Dialect interface
+func (mysqlDialect) Name() string { return "mysql" }
+
+// Driver implements Dialect interface
+func (mysqlDialect) Driver() string { return "mysql" }
+
+// Schemes implements Dialect interface
+func (mysqlDialect) Schemes() []string { return []string{"mysql"} }
+
+// Example implements Dialect interface
+func (mysqlDialect) Example() string {
+	return "mysql://user@host:port/dbname?charset=utf8mb4&parseTime=true"
+}
+
+// PasswordEnv implements Dialect interface
+func (mysqlDialect) PasswordEnv() string { return "MYSQL_PWD" }
+
+// TablesQuery implements Dialect interface
+func (mysqlDialect) TablesQuery() string {
+	return "SELECT table_name, table_type FROM information_schema.tables WHERE table_schema = DATABASE() ORDER BY table_name"
+}
+
+// DescribeQuery implements Dialect interface
+func (mysqlDialect) DescribeQuery() string {
+	return "SELECT ordinal_position AS position, column_name, column_type AS data_type, is_nullable, column_default FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position"
+}
+
+// Limit implements Dialect interface
+func (d mysqlDialect) Limit(stm string, offset, limit int) string {
+	if limit <= 0 && offset > 0 {
+		// MySQL requires LIMIT clause when OFFSET is used
+		return fmt.Sprintf("%s LIMIT 18446744073709551615 OFFSET %d", stm, offset)
+	}
+	return d.baseDialect.Limit(stm, offset, limit)
+}
+
+// QuoteIdent implements Dialect interface
+func (mysqlDialect) QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// QuoteString implements Dialect interface, MySQL treats backslash as escape character
+func (mysqlDialect) QuoteString(val string) string {
+	val = strings.ReplaceAll(val, `\`, `\\`)
+	return "'" + strings.ReplaceAll(val, "'", "''") + "'"
+}
+
+// TypeCategory implements Dialect interface
+func (mysqlDialect) TypeCategory(typeName string) string {
+	if strings.ToUpper(typeName) == "YEAR" {
+		return TypeInteger
+	}
+	return typeCategory(typeName)
+}
+
+// MySQLTLSConfig represents name of registered TLS config for MySQL driver
+const MySQLTLSConfig = "sqlshell"
+
+// DSN implements Dialect interface, it builds go-sql-driver/mysql DSN
+func (mysqlDialect) DSN(d *DSN) (string, error) {
+	var s string
+	if d.User != "" {
+		s = d.User
+		if d.Password != "" {
+			s += ":" + d.Password
+		}
+		s += "@"
+	}
+	if strings.HasPrefix(d.Host, "/") {
+		s += fmt.Sprintf("unix(%s)", d.Host)
+	} else if d.Host != "" {
+		port := d.Port
+		if port == "" {
+			port = "3306"
+		}
+		s += fmt.Sprintf("tcp(%s:%s)", d.Host, port)
+	}
+	s += "/" + d.Database
+	opts := url.Values{}
+	for _, key := range d.optionKeys() {
+		val := d.Options.Get(key)
+		switch key {
+		case "timezone":
+			opts.Set("loc", val)
+		case "connect_timeout":
+			opts.Set("timeout", timeoutDuration(val))
+		case "sslmode":
+			switch val {
+			case "disable":
+				opts.Set("tls", "false")
+			case "require", "allow", "prefer":
+				opts.Set("tls", "skip-verify")
+			case "verify-ca", "verify-full":
+				if d.Options.Get("sslrootcert") == "" {
+					opts.Set("tls", "true")
+				}
+			default:
+				return "", fmt.Errorf("unsupported sslmode=%s", val)
+			}
+		case "sslrootcert":
+			if d.Options.Get("sslmode") == "disable" {
+				continue
+			}
+			if err := registerMySQLTLS(val, d.Host, d.Options.Get("sslmode")); err != nil {
+				return "", err
+			}
+			opts.Set("tls", MySQLTLSConfig)
+		default:
+			opts.Set(key, val)
+		}
+	}
+	if len(opts) > 0 {
+		s += "?" + mysqlQuery(opts)
+	}
+	return s, nil
+}
+
+// helper function to register MySQL TLS config with given root certificate
+func registerMySQLTLS(fname, host, sslmode string) error {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return Error(err, ReaderErrorCode, fmt.Sprintf("unable to read sslrootcert %s", fname), "registerMySQLTLS")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("unable to parse sslrootcert %s", fname)
+	}
+	config := &tls.Config{RootCAs: pool, ServerName: host}
+	if sslmode == "verify-ca" {
+		// verify certificate chain but not the host name
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = func(certs [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(certs, pool)
+		}
+	}
+	return mysql.RegisterTLSConfig(MySQLTLSConfig, config)
+}
+
+// helper function to verify server certificate chain against given pool
+func verifyChain(certs [][]byte, pool *x509.CertPool) error {
+	if len(certs) == 0 {
+		return errors.New("no server certificate")
+	}
+	var chain []*x509.Certificate
+	for _, raw := range certs {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		chain = append(chain, cert)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{Roots: pool, Intermediates: intermediates})
+	return err
+}
+
+// helper function to encode query options, mysql driver unescapes values of
+// loc, tls and system variables, but uses comma separated charset list as is
+func mysqlQuery(opts url.Values) string {
+	var keys []string
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var out []string
+	for _, k := range keys {
+		val := strings.ReplaceAll(url.QueryEscape(opts.Get(k)), "%2C", ",")
+		out = append(out, fmt.Sprintf("%s=%s", k, val))
+	}
+	return strings.Join(out, "&")
+}
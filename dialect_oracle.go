@@ -0,0 +1,143 @@
+//go:build oracle
+
+package main
+
+// ORACLE dialect
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "github.com/mattn/go-oci8"
+)
+
+func init() {
+	RegisterDialect(oracleDialect{})
+}
+
+// oracleDialect represents ORACLE backend
+type oracleDialect struct {
+	baseDialect
+}
+
+// Name implements Dialect interface
+func (oracleDialect) Name() string { return "oracle" }
+
+// Driver implements Dialect interface
+func (oracleDialect) Driver() string { return "oci8" }
+
+// Schemes implements Dialect interface
+func (oracleDialect) Schemes() []string { return []string{"oracle", "oci8"} }
+
+// Example implements Dialect interface
+func (oracleDialect) Example() string { return "oracle://user@dbname" }
+
+// Placeholder implements Dialect interface
+func (oracleDialect) Placeholder(idx int) string { return fmt.Sprintf(":%d", idx) }
+
+// TablesQuery implements Dialect interface
+func (oracleDialect) TablesQuery() string {
+	return "SELECT table_name, 'TABLE' AS table_type FROM user_tables UNION ALL SELECT view_name, 'VIEW' FROM user_views ORDER BY 1"
+}
+
+// DescribeQuery implements Dialect interface
+func (oracleDialect) DescribeQuery() string {
+	return "SELECT column_id AS position, column_name, data_type, nullable AS is_nullable, data_default AS column_default FROM user_tab_columns WHERE table_name = UPPER(:1) ORDER BY column_id"
+}
+
+// Explain implements Dialect interface, ORACLE stores query plan in plan table
+// which we should query afterwards
+func (oracleDialect) Explain(stm string) []string {
+	return []string{
+		"EXPLAIN PLAN FOR " + stm,
+		"SELECT plan_table_output FROM TABLE(DBMS_XPLAN.DISPLAY())",
+	}
+}
+
+// Limit implements Dialect interface, it either uses FETCH FIRST syntax
+// of ORACLE 12c+ or wraps statement into ROWNUM based query
+func (oracleDialect) Limit(stm string, offset, limit int) string {
+	if limit <= 0 && offset <= 0 {
+		return stm
+	}
+	if FETCHFIRST {
+		if offset > 0 {
+			stm = fmt.Sprintf("%s OFFSET %d ROWS", stm, offset)
+		}
+		if limit > 0 {
+			stm = fmt.Sprintf("%s FETCH NEXT %d ROWS ONLY", stm, limit)
+		}
+		return stm
+	}
+	if offset <= 0 {
+		return fmt.Sprintf("SELECT * FROM (%s) WHERE ROWNUM <= %d", stm, limit)
+	}
+	inner := fmt.Sprintf("SELECT sqlsh_q.*, ROWNUM %s FROM (%s) sqlsh_q", RowNumColumn, stm)
+	if limit > 0 {
+		inner = fmt.Sprintf("%s WHERE ROWNUM <= %d", inner, offset+limit)
+	}
+	return fmt.Sprintf("SELECT * FROM (%s) WHERE %s > %d", inner, RowNumColumn, offset)
+}
+
+// QuoteBinary implements Dialect interface
+func (oracleDialect) QuoteBinary(val []byte) string {
+	return fmt.Sprintf("HEXTORAW('%X')", val)
+}
+
+// QuoteBool implements Dialect interface, ORACLE SQL has no boolean literals
+func (oracleDialect) QuoteBool(val bool) string {
+	if val {
+		return "1"
+	}
+	return "0"
+}
+
+// TypeCategory implements Dialect interface
+func (oracleDialect) TypeCategory(typeName string) string {
+	name := strings.ToUpper(strings.TrimSpace(typeName))
+	if name == "DATE" {
+		// ORACLE DATE type holds time as well
+		return TypeTime
+	}
+	return typeCategory(name)
+}
+
+// DSN implements Dialect interface, it builds go-oci8 DSN
+func (oracleDialect) DSN(d *DSN) (string, error) {
+	var s string
+	if d.User != "" {
+		s = d.User
+		if d.Password != "" {
+			s += "/" + d.Password
+		}
+		s += "@"
+	}
+	if d.Host == "" {
+		// ORACLE DB alias name, e.g. oracle://user:password@/dbname
+		s += d.Database
+	} else {
+		s += d.Host
+		if d.Port != "" {
+			s += ":" + d.Port
+		}
+		if d.Database != "" {
+			s += "/" + d.Database
+		}
+	}
+	opts := url.Values{}
+	for _, key := range d.optionKeys() {
+		val := d.Options.Get(key)
+		switch key {
+		case "timezone":
+			opts.Set("loc", val)
+		default:
+			opts.Set(key, val)
+		}
+	}
+	if len(opts) > 0 {
+		s += "?" + opts.Encode()
+	}
+	return s, nil
+}
@@ -0,0 +1,97 @@
+//go:build postgres || !(oracle || sqlite_cgo || sqlite_pure || mysql || postgres || duckdb)
+
+package main
+
+// Postgres dialect
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+
+import (
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+func init() {
+	RegisterDialect(postgresDialect{})
+}
+
+// postgresDialect represents Postgres backend
+type postgresDialect struct {
+	baseDialect
+}
+
+// Name implements Dialect interface
+func (postgresDialect) Name() string { return "postgres" }
+
+// Driver implements Dialect interface
+func (postgresDialect) Driver() string { return "postgres" }
+
+// Schemes implements Dialect interface
+func (postgresDialect) Schemes() []string { return []string{"postgres", "postgresql"} }
+
+// Example implements Dialect interface
+func (postgresDialect) Example() string {
+	return "postgres://user@host:port/dbname?sslmode=verify-full"
+}
+
+// PasswordEnv implements Dialect interface
+func (postgresDialect) PasswordEnv() string { return "PGPASSWORD" }
+
+// Placeholder implements Dialect interface
+func (postgresDialect) Placeholder(idx int) string { return fmt.Sprintf("$%d", idx) }
+
+// TablesQuery implements Dialect interface
+func (postgresDialect) TablesQuery() string {
+	return "SELECT table_name, table_type FROM information_schema.tables WHERE table_schema NOT IN ('pg_catalog', 'information_schema') ORDER BY table_name"
+}
+
+// DescribeQuery implements Dialect interface
+func (postgresDialect) DescribeQuery() string {
+	return "SELECT ordinal_position AS position, column_name, data_type, is_nullable, column_default FROM information_schema.columns WHERE table_name = $1 ORDER BY ordinal_position"
+}
+
+// TypeCategory implements Dialect interface
+func (postgresDialect) TypeCategory(typeName string) string {
+	name := strings.ToUpper(strings.TrimSpace(typeName))
+	if strings.HasPrefix(name, "_") || strings.HasSuffix(name, "[]") {
+		// lib/pq reports array types with underscore prefix, e.g. _INT4
+		return TypeList
+	}
+	return typeCategory(name)
+}
+
+// helper function to quote value of postgres keyword DSN
+func pgQuote(val string) string {
+	if val != "" && !strings.ContainsAny(val, " '\\") {
+		return val
+	}
+	val = strings.ReplaceAll(val, `\`, `\\`)
+	val = strings.ReplaceAll(val, `'`, `\'`)
+	return fmt.Sprintf("'%s'", val)
+}
+
+// QuoteBinary implements Dialect interface, it uses bytea hex format
+func (postgresDialect) QuoteBinary(val []byte) string {
+	return fmt.Sprintf("'\\x%x'::bytea", val)
+}
+
+// DSN implements Dialect interface, it builds lib/pq keyword based DSN
+func (postgresDialect) DSN(d *DSN) (string, error) {
+	var out []string
+	add := func(key, val string) {
+		if val != "" {
+			out = append(out, fmt.Sprintf("%s=%s", key, pgQuote(val)))
+		}
+	}
+	add("host", d.Host)
+	add("port", d.Port)
+	add("user", d.User)
+	add("password", d.Password)
+	add("dbname", d.Database)
+	for _, key := range d.optionKeys() {
+		// all other options, e.g. timezone, are passed as run-time parameters
+		add(key, d.Options.Get(key))
+	}
+	return strings.Join(out, " "), nil
+}
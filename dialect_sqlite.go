@@ -0,0 +1,113 @@
+//go:build sqlite_cgo || sqlite_pure || !(oracle || sqlite_cgo || sqlite_pure || mysql || postgres || duckdb)
+
+package main
+
+// SQLite dialect
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// SQLite driver is provided either by cgo based github.com/mattn/go-sqlite3
+// (sqlite_cgo build tag) or pure Go modernc.org/sqlite (sqlite_pure build tag),
+// see driver_sqlite_cgo.go and driver_sqlite_pure.go files
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterDialect(sqliteDialect{})
+}
+
+// sqliteDialect represents SQLite backend
+type sqliteDialect struct {
+	baseDialect
+}
+
+// Name implements Dialect interface
+func (sqliteDialect) Name() string { return "sqlite" }
+
+// Driver implements Dialect interface
+func (sqliteDialect) Driver() string { return sqliteDriver }
+
+// Schemes implements Dialect interface
+func (sqliteDialect) Schemes() []string { return []string{"sqlite", "sqlite3"} }
+
+// Example implements Dialect interface
+func (sqliteDialect) Example() string { return "sqlite:///path/file.db?mode=ro" }
+
+// FileBased implements Dialect interface
+func (sqliteDialect) FileBased() bool { return true }
+
+// QuoteTime implements Dialect interface, SQLite has no typed literals and
+// keeps time values as text
+func (d sqliteDialect) QuoteTime(val time.Time, category string) string {
+	switch category {
+	case TypeDate:
+		return d.QuoteString(val.Format(DateFormat))
+	case TypeTimeOfDay:
+		return d.QuoteString(val.Format(TimeOfDayFormat))
+	}
+	return d.QuoteString(val.Format(TimestampFormat))
+}
+
+// TablesQuery implements Dialect interface
+func (sqliteDialect) TablesQuery() string {
+	return "SELECT name AS table_name, type AS table_type FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name"
+}
+
+// DescribeQuery implements Dialect interface
+func (sqliteDialect) DescribeQuery() string {
+	return "SELECT cid AS position, name AS column_name, type AS data_type, CASE \"notnull\" WHEN 1 THEN 'NO' ELSE 'YES' END AS is_nullable, dflt_value AS column_default FROM pragma_table_info(?) ORDER BY cid"
+}
+
+// Explain implements Dialect interface
+func (sqliteDialect) Explain(stm string) []string {
+	return []string{"EXPLAIN QUERY PLAN " + stm}
+}
+
+// Limit implements Dialect interface
+func (d sqliteDialect) Limit(stm string, offset, limit int) string {
+	if limit <= 0 && offset > 0 {
+		// SQLite requires LIMIT clause when OFFSET is used
+		return fmt.Sprintf("%s LIMIT -1 OFFSET %d", stm, offset)
+	}
+	return d.baseDialect.Limit(stm, offset, limit)
+}
+
+// DSN implements Dialect interface, it builds SQLite driver DSN
+func (sqliteDialect) DSN(d *DSN) (string, error) {
+	path := d.Database
+	if path == "" {
+		return "", errors.New("empty sqlite file path, use sqlite:///path/file.db or sqlite://:memory:")
+	}
+	if len(d.Options) == 0 {
+		return path, nil
+	}
+	opts := url.Values{}
+	for _, key := range d.optionKeys() {
+		val := d.Options.Get(key)
+		switch key {
+		case "journal_mode", "busy_timeout", "foreign_keys", "synchronous", "locking_mode", "cache_size":
+			sqlitePragma(opts, key, val)
+		case "connect_timeout":
+			// sqlite busy timeout is in milliseconds
+			if v, err := strconv.Atoi(val); err == nil {
+				val = strconv.Itoa(v * 1000)
+			}
+			sqlitePragma(opts, "busy_timeout", val)
+		case "timezone":
+			sqliteTimezone(opts, val)
+		default:
+			opts.Set(key, val)
+		}
+	}
+	// mode and cache options are only recognized in SQLite URI filenames
+	if !strings.HasPrefix(path, "file:") {
+		path = "file:" + path
+	}
+	return path + "?" + opts.Encode(), nil
+}
@@ -0,0 +1,142 @@
+package main
+
+// display module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// display module draws prompt and edited input line in terminal. Input line
+// longer than terminal width wraps into several terminal rows, therefore we
+// keep the row of the cursor to find the prompt row when the line is drawn
+// again. Width of characters is calculated by grapheme clusters, i.e. wide
+// (CJK, emoji) characters take two columns and combining marks do not take
+// any column. When terminal is resized, terminal reflows wrapped rows and we
+// recalculate cursor row for new terminal width.
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"atomicgo.dev/cursor"
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+	"golang.org/x/term"
+)
+
+// DefaultWidth represents terminal width used when it can't be determined
+const DefaultWidth = 80
+
+// lineView represents prompt and input line drawn in terminal
+type lineView struct {
+	prompt string // prompt without color codes
+	text   string // shown text of input line
+	pos    int    // cursor position in the text
+	row    int    // terminal row of cursor counted from the prompt row
+}
+
+// view represents currently drawn input line
+var view lineView
+
+// displayReplacer replaces characters of pasted text which do not fit single line
+var displayReplacer = strings.NewReplacer("\n", "↵", "\t", " ")
+
+// helper function to return text as it is shown in the prompt, new lines are
+// shown as ↵ and tabs as spaces, i.e. each character keeps its position
+func displayText(text string) string {
+	return displayReplacer.Replace(text)
+}
+
+// helper function to return width of terminal
+func displayWidth() int {
+	fd := int(os.Stdout.Fd())
+	if term.IsTerminal(fd) {
+		if width, _, err := term.GetSize(fd); err == nil && width > 0 {
+			return width
+		}
+	}
+	return DefaultWidth
+}
+
+// helper function to return terminal row and column of cursor at given
+// position of text shown after prompt, and row and column of end of text,
+// characters which do not fit the row are moved to the next row
+func lineLayout(prompt, text string, pos, width int) (int, int, int, int) {
+	var row, col, idx int
+	curRow, curCol := -1, 0
+	place := func(cluster string) {
+		w := runewidth.StringWidth(cluster)
+		if col+w > width {
+			row++
+			col = 0
+		}
+		col += w
+	}
+	// position after the last column is shown at the beginning of next row
+	position := func() (int, int) {
+		if col >= width {
+			return row + 1, 0
+		}
+		return row, col
+	}
+	g := uniseg.NewGraphemes(prompt)
+	for g.Next() {
+		place(g.Str())
+	}
+	g = uniseg.NewGraphemes(text)
+	for g.Next() {
+		if curRow < 0 && idx >= pos {
+			curRow, curCol = position()
+		}
+		place(g.Str())
+		idx += len(g.Runes())
+	}
+	endRow, endCol := position()
+	if curRow < 0 {
+		curRow, curCol = endRow, endCol
+	}
+	return curRow, curCol, endRow, endCol
+}
+
+// Draw draws prompt and text in place of previously drawn line, the styled
+// string is the prompt and text with color codes, pos is cursor position in
+// the text
+func (v *lineView) Draw(prompt, styled, text string, pos int) {
+	width := displayWidth()
+	row, col, endRow, endCol := lineLayout(prompt, text, pos, width)
+	if v.row > 0 {
+		cursor.StartOfLineUp(v.row)
+	} else {
+		cursor.StartOfLine()
+	}
+	// clear all rows of previous line
+	fmt.Print("\033[J" + styled)
+	if endCol == 0 && endRow > 0 {
+		// text ends at the last column, move cursor to the next row
+		fmt.Print("\r\n")
+	}
+	if endRow > row {
+		cursor.Up(endRow - row)
+	}
+	cursor.HorizontalAbsolute(col)
+	v.prompt, v.text, v.pos, v.row = prompt, text, pos, row
+}
+
+// Resize recalculates cursor row of drawn line for current terminal width
+func (v *lineView) Resize() {
+	v.row, _, _, _ = lineLayout(v.prompt, v.text, v.pos, displayWidth())
+}
+
+// Finish moves cursor to the end of drawn line, such that following output
+// does not overwrite its rows, and starts new line
+func (v *lineView) Finish() {
+	_, _, endRow, endCol := lineLayout(v.prompt, v.text, v.pos, displayWidth())
+	if endRow > v.row {
+		cursor.Down(endRow - v.row)
+	}
+	cursor.HorizontalAbsolute(endCol)
+	v.Reset()
+}
+
+// Reset starts new line, e.g. when prompt is printed after command output
+func (v *lineView) Reset() {
+	*v = lineView{}
+}
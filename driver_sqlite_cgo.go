@@ -0,0 +1,25 @@
+//go:build sqlite_cgo || (cgo && !(oracle || sqlite_cgo || sqlite_pure || mysql || postgres || duckdb))
+
+package main
+
+// SQLite cgo driver
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+
+import (
+	"net/url"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriver represents name of SQLite Go sql driver
+const sqliteDriver = "sqlite3"
+
+// helper function to set SQLite pragma in go-sqlite3 DSN options, e.g. _journal_mode=WAL
+func sqlitePragma(opts url.Values, key, val string) {
+	opts.Set("_"+key, val)
+}
+
+// helper function to set timezone in go-sqlite3 DSN options
+func sqliteTimezone(opts url.Values, val string) {
+	opts.Set("_loc", val)
+}
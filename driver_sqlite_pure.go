@@ -0,0 +1,27 @@
+//go:build (sqlite_pure && !sqlite_cgo) || (!cgo && !(oracle || sqlite_cgo || sqlite_pure || mysql || postgres || duckdb))
+
+package main
+
+// SQLite pure Go driver
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDriver represents name of SQLite Go sql driver
+const sqliteDriver = "sqlite"
+
+// helper function to set SQLite pragma in modernc.org/sqlite DSN options, e.g. _pragma=journal_mode(WAL)
+func sqlitePragma(opts url.Values, key, val string) {
+	opts.Add("_pragma", fmt.Sprintf("%s(%s)", key, val))
+}
+
+// helper function to set timezone in modernc.org/sqlite DSN options
+func sqliteTimezone(opts url.Values, val string) {
+	log.Printf("WARNING: timezone=%s option is not supported by pure Go SQLite driver", val)
+}
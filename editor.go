@@ -0,0 +1,120 @@
+package main
+
+// editor module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// SQL statements can be edited in external editor defined by VISUAL or
+// EDITOR environment variables (vi by default), see edit command, Ctrl-X
+// Ctrl-E keys and v command of vi mode. The current input line, or the last
+// executed statement if it is empty, is written to temporary file and
+// statements of the saved file are executed. The keyboard handler keeps
+// terminal in raw mode, therefore we restore original terminal state while
+// editor is running.
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// termState represents terminal state before keyboard handler switched it to raw mode
+var termState *term.State
+
+// helper function to save terminal state before keyboard handler starts
+func saveTermState() {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		termState, _ = term.GetState(fd)
+	}
+}
+
+// helper function to return editor command
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if args := strings.Fields(os.Getenv(env)); len(args) > 0 {
+			return args
+		}
+	}
+	return []string{"vi"}
+}
+
+// helper function to edit given text in external editor and return edited text
+func editText(text string) (string, error) {
+	file, err := os.CreateTemp("", "sqlsh-*.sql")
+	if err != nil {
+		return "", err
+	}
+	fname := file.Name()
+	defer os.Remove(fname)
+	if text != "" {
+		text += "\n"
+	}
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	// run editor in original terminal mode and switch back to raw mode afterwards
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		if raw, err := term.GetState(fd); err == nil {
+			defer term.Restore(fd, raw)
+		}
+		if termState != nil {
+			term.Restore(fd, termState)
+		}
+		fmt.Print(PasteModeOff)
+		defer fmt.Print(PasteModeOn)
+	}
+	args := editorCommand()
+	cmd := exec.Command(args[0], append(args[1:], fname)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %v", args[0], err)
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// helper function to convert multi-line text to single input line
+func inputLine(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.TrimSpace(strings.ReplaceAll(text, "\n", " "))
+}
+
+// helper function to edit statement in external editor and split edited text into statements
+func editStatements(text string) ([]string, error) {
+	edited, err := editText(text)
+	if err != nil {
+		return nil, err
+	}
+	return splitStatements(edited), nil
+}
+
+// helper function to return last executed statement from the history
+func lastStatement(history []string) string {
+	for idx := len(history) - 1; idx >= 0; idx-- {
+		cmd := strings.TrimSpace(history[idx])
+		if cmd != "" && !isEditCommand(cmd) {
+			return cmd
+		}
+	}
+	return ""
+}
+
+// helper function to check if given input is edit command
+func isEditCommand(command string) bool {
+	command = strings.Trim(command, " ;")
+	return command == "edit" || command == `\e`
+}
@@ -4,9 +4,12 @@ package main
 // Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/gookit/color"
 )
 
 // GenericErr represents generic dbs error
@@ -111,7 +114,6 @@ func (e *DBError) Explain() string {
 	default:
 		return "Not defined"
 	}
-	return "Not defined"
 }
 
 // helper function to create dbs error
@@ -127,3 +129,46 @@ func Error(err error, code int, msg, function string) error {
 		Function: function,
 	}
 }
+
+// reportedError represents error which is already printed as part of the output
+type reportedError struct {
+	err error
+}
+
+// Error implements error interface
+func (e *reportedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns original error
+func (e *reportedError) Unwrap() error {
+	return e.err
+}
+
+// helper function to return error as JSON object, e.g. {"error": {"reason": ..., "code": ...}}
+func jsonError(err error) ([]byte, error) {
+	var dbErr *DBError
+	if !errors.As(err, &dbErr) {
+		dbErr = &DBError{Reason: err.Error(), Code: GenericErrorCode}
+	}
+	return json.Marshal(map[string]*DBError{"error": dbErr})
+}
+
+// helper function to print error, in JSON based formats error is printed
+// as JSON object, e.g. {"error": {"reason": ..., "code": ...}}
+func printError(err error) {
+	var reported *reportedError
+	if errors.As(err, &reported) {
+		return
+	}
+	if !jsonFormat() {
+		color.Error.Println("ERROR:", err)
+		return
+	}
+	data, e := jsonError(err)
+	if e != nil {
+		color.Error.Println("ERROR:", err)
+		return
+	}
+	fmt.Println(string(data))
+}
@@ -0,0 +1,473 @@
+package main
+
+// export module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// export command writes results of SELECT statement into a file, e.g.
+// export format=parquet file=out.parquet select * from table
+// export format=arrow file=out.arrow select * from table
+// export format=xlsx file=out.xlsx sheet=Name select * from table
+//
+// Column types of the query are mapped to typed columnar schema and records
+// are written in batches (parquet row groups or arrow record batches) such
+// that memory usage does not depend on number of exported records.
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/decimal128"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/apache/arrow/go/v12/parquet"
+	"github.com/apache/arrow/go/v12/parquet/compress"
+	"github.com/apache/arrow/go/v12/parquet/pqarrow"
+)
+
+// ExportBatchSize represents number of records written as single row group or record batch
+var ExportBatchSize = 10000
+
+// ExportFormats represents supported export formats and their file extensions
+var ExportFormats = map[string][]string{
+	"parquet": {".parquet", ".pq"},
+	"arrow":   {".arrow", ".ipc", ".feather"},
+	"xlsx":    {".xlsx"},
+}
+
+// exportWriter represents writer of exported records
+type exportWriter interface {
+	Append(values []interface{}) error // append scanned DB values of single record
+	Close() error                      // write buffered records and close the file
+}
+
+// helper function to handle export command, e.g.
+// export format=parquet file=out.parquet select * from table
+func exportCommand(input string) error {
+	kvs, stm := parseExportCommand(input)
+	fname := kvs["file"]
+	if fname == "" {
+		return errors.New("usage: export format=<parquet|arrow|xlsx> file=<file> [sheet=<name>] <sql>")
+	}
+	format := strings.ToLower(kvs["format"])
+	if format == "" {
+		format = exportFormat(fname)
+	}
+	if _, ok := ExportFormats[format]; !ok {
+		return fmt.Errorf("unsupported export format '%s'", format)
+	}
+	stm, args := parseDBStatement(stm)
+	lstm := strings.ToLower(strings.TrimSpace(stm))
+	if !strings.HasPrefix(lstm, "select") && !strings.HasPrefix(lstm, "with") {
+		return errors.New("only SELECT statements can be exported")
+	}
+	return export(stm, format, fname, kvs, args...)
+}
+
+// helper function to split export command into key=value options and SQL statement
+func parseExportCommand(input string) (map[string]string, string) {
+	kvs := make(map[string]string)
+	rest := strings.TrimSpace(strings.TrimPrefix(input, "export"))
+	for rest != "" {
+		field := rest
+		if idx := strings.IndexAny(rest, " \t\n"); idx >= 0 {
+			field = rest[:idx]
+		}
+		arr := strings.SplitN(field, "=", 2)
+		if len(arr) != 2 {
+			break
+		}
+		kvs[strings.ToLower(arr[0])] = arr[1]
+		rest = strings.TrimSpace(rest[len(field):])
+	}
+	return kvs, rest
+}
+
+// helper function to guess export format from file extension
+func exportFormat(fname string) string {
+	ext := strings.ToLower(filepath.Ext(fname))
+	for format, exts := range ExportFormats {
+		for _, e := range exts {
+			if e == ext {
+				return format
+			}
+		}
+	}
+	return ""
+}
+
+// helper function to export results of given statement into file of given format
+func export(stm, format, fname string, opts map[string]string, args ...interface{}) error {
+	stm = cleanStatement(stm)
+	began := time.Now()
+	tx, err := DB.Begin()
+	if err != nil {
+		return Error(err, TransactionErrorCode, "", "export")
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query(stm, args...)
+	if err != nil {
+		return Error(err, QueryErrorCode, "unable to query statement", "export")
+	}
+	defer rows.Close()
+	columns, err := queryColumns(rows)
+	if err != nil {
+		return Error(err, QueryErrorCode, "unable to get columns", "export")
+	}
+
+	// records are written into temporary file which replaces the export
+	// file on success, i.e. existing file is not left half written on error
+	tmp := filepath.Join(filepath.Dir(fname), ".sqlsh-"+filepath.Base(fname))
+	defer os.Remove(tmp)
+	writer, err := newExportWriter(format, fname, tmp, columns, opts)
+	if err != nil {
+		return Error(err, WriterErrorCode, "unable to create export file", "export")
+	}
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range columns {
+		valuePtrs[i] = &values[i]
+	}
+	var count int
+	for rows.Next() {
+		if err := rows.Scan(valuePtrs...); err != nil {
+			writer.Close()
+			return Error(err, RowsScanErrorCode, "", "export")
+		}
+		if err := writer.Append(values); err != nil {
+			writer.Close()
+			return Error(err, WriterErrorCode, "", "export")
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		writer.Close()
+		return Error(err, RowsScanErrorCode, "", "export")
+	}
+	if err := writer.Close(); err != nil {
+		return Error(err, WriterErrorCode, "", "export")
+	}
+	if err := os.Rename(tmp, fname); err != nil {
+		return Error(err, WriterErrorCode, "unable to create export file", "export")
+	}
+	fmt.Printf("exported %d records to %s in %v\n", count, fname, time.Since(began))
+	return nil
+}
+
+// helper function to create writer of given export format, records are
+// written into out file, export file is only read, e.g. xlsx workbook
+func newExportWriter(format, fname, out string, columns []Column, opts map[string]string) (exportWriter, error) {
+	switch format {
+	case "parquet", "arrow":
+		return newColumnarWriter(format, out, columns)
+	case "xlsx":
+		return newXLSXWriter(fname, out, opts["sheet"], columns)
+	}
+	return nil, fmt.Errorf("unsupported export format '%s'", format)
+}
+
+// columnarWriter writes records in parquet or arrow IPC file format
+type columnarWriter struct {
+	columns []Column
+	schema  *arrow.Schema
+	builder *array.RecordBuilder
+	file    *os.File
+	write   func(rec arrow.Record) error // writes single batch of records
+	close   func() error                 // closes format writer
+	size    int                          // number of buffered records
+}
+
+// helper function to create columnar writer for given columns
+func newColumnarWriter(format, fname string, columns []Column) (*columnarWriter, error) {
+	schema := arrowSchema(columns)
+	file, err := os.Create(fname)
+	if err != nil {
+		return nil, err
+	}
+	w := &columnarWriter{
+		columns: columns,
+		schema:  schema,
+		builder: array.NewRecordBuilder(memory.DefaultAllocator, schema),
+		file:    file,
+	}
+	if format == "parquet" {
+		props := parquet.NewWriterProperties(
+			parquet.WithCompression(compress.Codecs.Snappy),
+			parquet.WithMaxRowGroupLength(int64(ExportBatchSize)))
+		fw, err := pqarrow.NewFileWriter(schema, file, props, pqarrow.DefaultWriterProps())
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		// parquet writer closes underlying file
+		w.write, w.close = fw.Write, fw.Close
+	} else {
+		fw, err := ipc.NewFileWriter(file, ipc.WithSchema(schema))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		w.write = fw.Write
+		w.close = func() error {
+			if err := fw.Close(); err != nil {
+				file.Close()
+				return err
+			}
+			return file.Close()
+		}
+	}
+	return w, nil
+}
+
+// Append appends DB values to record batch, full batches are written to the file
+func (w *columnarWriter) Append(values []interface{}) error {
+	for i, val := range values {
+		if err := appendArrowValue(w.builder.Field(i), val, w.columns[i]); err != nil {
+			return fmt.Errorf("column %s: %v", w.columns[i].Name, err)
+		}
+	}
+	w.size++
+	if w.size >= ExportBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// helper function to write buffered records as single batch
+func (w *columnarWriter) flush() error {
+	if w.size == 0 {
+		return nil
+	}
+	rec := w.builder.NewRecord()
+	defer rec.Release()
+	w.size = 0
+	return w.write(rec)
+}
+
+// Close writes buffered records and closes the file
+func (w *columnarWriter) Close() error {
+	defer w.builder.Release()
+	if err := w.flush(); err != nil {
+		w.close()
+		return err
+	}
+	return w.close()
+}
+
+// helper function to map query columns to arrow schema
+func arrowSchema(columns []Column) *arrow.Schema {
+	fields := make([]arrow.Field, len(columns))
+	for i, col := range columns {
+		fields[i] = arrow.Field{Name: col.Name, Type: arrowType(col), Nullable: true}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// helper function to map column type category to arrow data type
+func arrowType(col Column) arrow.DataType {
+	switch col.Category {
+	case TypeInteger:
+		return arrow.PrimitiveTypes.Int64
+	case TypeFloat:
+		return arrow.PrimitiveTypes.Float64
+	case TypeDecimal:
+		// decimals without known precision are kept as text
+		if col.Precision != nil && col.Scale != nil && *col.Precision > 0 && *col.Precision <= 38 {
+			return &arrow.Decimal128Type{Precision: int32(*col.Precision), Scale: int32(*col.Scale)}
+		}
+	case TypeBool:
+		return arrow.FixedWidthTypes.Boolean
+	case TypeDate:
+		return arrow.FixedWidthTypes.Date32
+	case TypeTime:
+		return arrow.FixedWidthTypes.Timestamp_us
+	case TypeTimeOfDay:
+		return arrow.FixedWidthTypes.Time64us
+	case TypeBinary:
+		return arrow.BinaryTypes.Binary
+	}
+	return arrow.BinaryTypes.String
+}
+
+// helper function to append DB value to arrow builder of its column
+func appendArrowValue(b array.Builder, val interface{}, col Column) error {
+	if val == nil {
+		b.AppendNull()
+		return nil
+	}
+	switch builder := b.(type) {
+	case *array.Int64Builder:
+		v, err := int64Value(val)
+		if err != nil {
+			return err
+		}
+		builder.Append(v)
+	case *array.Float64Builder:
+		v, err := float64Value(val)
+		if err != nil {
+			return err
+		}
+		builder.Append(v)
+	case *array.Decimal128Builder:
+		dtype := builder.Type().(*arrow.Decimal128Type)
+		v, err := decimal128.FromString(stringValue(columnValue(val, col)), dtype.Precision, dtype.Scale)
+		if err != nil {
+			return err
+		}
+		builder.Append(v)
+	case *array.BooleanBuilder:
+		v, err := boolValue(val)
+		if err != nil {
+			return err
+		}
+		builder.Append(v)
+	case *array.Date32Builder:
+		v, err := timeOf(val, DateFormat)
+		if err != nil {
+			return err
+		}
+		builder.Append(arrow.Date32FromTime(v))
+	case *array.TimestampBuilder:
+		v, err := timeOf(val, time.RFC3339Nano)
+		if err != nil {
+			return err
+		}
+		builder.Append(arrow.Timestamp(v.UnixMicro()))
+	case *array.Time64Builder:
+		v, err := timeOfDay(val)
+		if err != nil {
+			return err
+		}
+		builder.Append(arrow.Time64(v.Microseconds()))
+	case *array.BinaryBuilder:
+		switch v := val.(type) {
+		case []byte:
+			builder.Append(v)
+		default:
+			builder.AppendString(stringValue(v))
+		}
+	case *array.StringBuilder:
+		builder.Append(stringValue(columnValue(val, col)))
+	default:
+		return fmt.Errorf("unsupported arrow type %s", b.Type())
+	}
+	return nil
+}
+
+// helper function to convert value to string without NULL marker
+func stringValue(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return formatValue(val)
+}
+
+// helper function to convert DB value to int64
+func int64Value(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("integer value %d is out of range", v)
+		}
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case float64:
+		return floatInt64(v)
+	case float32:
+		return floatInt64(float64(v))
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return strconv.ParseInt(strings.TrimSpace(stringValue(val)), 10, 64)
+}
+
+// helper function to convert float value to int64, it fails for fractional
+// values and values out of int64 range
+func floatInt64(v float64) (int64, error) {
+	if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+		return 0, fmt.Errorf("value %v is not an integer", v)
+	}
+	return int64(v), nil
+}
+
+// helper function to convert DB value to float64
+func float64Value(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	}
+	if i, err := int64Value(val); err == nil {
+		return float64(i), nil
+	}
+	return strconv.ParseFloat(strings.TrimSpace(stringValue(val)), 64)
+}
+
+// helper function to convert DB value to bool
+func boolValue(val interface{}) (bool, error) {
+	if v, ok := val.(bool); ok {
+		return v, nil
+	}
+	switch s := strings.ToLower(strings.TrimSpace(stringValue(val))); s {
+	case "t", "true", "y", "yes", "on":
+		return true, nil
+	case "f", "false", "n", "no", "off":
+		return false, nil
+	}
+	i, err := int64Value(val)
+	return i != 0, err
+}
+
+// helper function to convert DB value to time, text values are parsed with given layout
+func timeOf(val interface{}, layout string) (time.Time, error) {
+	if v, ok := val.(time.Time); ok {
+		return v, nil
+	}
+	s := strings.TrimSpace(stringValue(val))
+	for _, l := range []string{layout, time.RFC3339Nano, "2006-01-02 15:04:05.999999999", DateFormat, TimeOfDayFormat} {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse time value '%s'", s)
+}
+
+// helper function to convert DB time of day value to duration since midnight
+func timeOfDay(val interface{}) (time.Duration, error) {
+	t, err := timeOf(val, TimeOfDayFormat)
+	if err != nil {
+		return 0, err
+	}
+	hour, min, sec := t.Clock()
+	clock := time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+	return clock + time.Duration(t.Nanosecond()), nil
+}
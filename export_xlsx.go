@@ -0,0 +1,248 @@
+package main
+
+// export xlsx module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// xlsx export writes results of SELECT statement into Office Open XML
+// workbook, e.g.
+// export format=xlsx file=report.xlsx sheet=Users select * from users
+//
+// Numbers, booleans, dates and timestamps are written as typed cells, all
+// other values as text, which keeps leading zeros of codes. Exports into
+// existing workbook add new sheet or replace the sheet with the same name.
+// Like rows format we buffer only a sample of first records to size columns,
+// all other records are streamed into the workbook.
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// XLSXMaxWidth represents maximum width of auto-sized columns of xlsx export
+const XLSXMaxWidth = 60
+
+// XLSXDateFormat represents number format of date cells
+const XLSXDateFormat = "yyyy-mm-dd"
+
+// XLSXTimeFormat represents number format of timestamp cells
+const XLSXTimeFormat = "yyyy-mm-dd hh:mm:ss"
+
+// XLSXTimeOfDayFormat represents number format of time of day cells
+const XLSXTimeOfDayFormat = "hh:mm:ss"
+
+// xlsxWriter writes records into sheet of xlsx workbook
+type xlsxWriter struct {
+	file    *excelize.File
+	fname   string
+	stream  *excelize.StreamWriter
+	columns []Column
+	styles  []int           // cell styles of columns
+	widths  []int           // column widths
+	sample  [][]interface{} // buffered cells of sampled records
+	row     int             // number of written rows including header
+}
+
+// helper function to create xlsx writer, new sheet is added to existing
+// workbook and the workbook is saved into out file
+func newXLSXWriter(fname, out, sheet string, columns []Column) (*xlsxWriter, error) {
+	var file *excelize.File
+	if _, err := os.Stat(fname); err == nil {
+		file, err = excelize.OpenFile(fname)
+		if err != nil {
+			return nil, err
+		}
+		if sheet == "" {
+			sheet = fmt.Sprintf("Sheet%d", file.SheetCount+1)
+		}
+	} else {
+		file = excelize.NewFile()
+		if sheet == "" {
+			sheet = "Sheet1"
+		}
+		if err := file.SetSheetName("Sheet1", sheet); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	// stream writer replaces content of existing sheet
+	idx, err := file.GetSheetIndex(sheet)
+	if err == nil && idx < 0 {
+		idx, err = file.NewSheet(sheet)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	file.SetActiveSheet(idx)
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	w := &xlsxWriter{
+		file:    file,
+		fname:   out,
+		stream:  stream,
+		columns: columns,
+		styles:  make([]int, len(columns)),
+		widths:  make([]int, len(columns)),
+	}
+	if err := w.setStyles(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	header := make([]interface{}, len(columns))
+	bold, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	for i, col := range columns {
+		header[i] = excelize.Cell{StyleID: bold, Value: col.Name}
+	}
+	w.measure(header)
+	w.sample = append(w.sample, header)
+	return w, nil
+}
+
+// helper function to create cell styles of date and timestamp columns
+func (w *xlsxWriter) setStyles() error {
+	formats := map[string]string{TypeDate: XLSXDateFormat, TypeTime: XLSXTimeFormat, TypeTimeOfDay: XLSXTimeOfDayFormat}
+	for i, col := range w.columns {
+		format, ok := formats[col.Category]
+		if !ok {
+			continue
+		}
+		style, err := w.file.NewStyle(&excelize.Style{CustomNumFmt: &format})
+		if err != nil {
+			return err
+		}
+		w.styles[i] = style
+	}
+	return nil
+}
+
+// Append converts DB values to typed cells, until sample is collected cells are buffered
+func (w *xlsxWriter) Append(values []interface{}) error {
+	cells := make([]interface{}, len(values))
+	for i, val := range values {
+		cells[i] = excelize.Cell{StyleID: w.styles[i], Value: xlsxValue(val, w.columns[i])}
+	}
+	if w.sample != nil {
+		w.measure(cells)
+		w.sample = append(w.sample, cells)
+		if len(w.sample) <= SampleSize {
+			return nil
+		}
+		return w.flushSample()
+	}
+	return w.writeRow(cells)
+}
+
+// helper function to update column widths from given cells
+func (w *xlsxWriter) measure(cells []interface{}) {
+	for i, cell := range cells {
+		var width int
+		switch v := cell.(excelize.Cell).Value.(type) {
+		case nil:
+			continue
+		case time.Time:
+			width = len(XLSXTimeFormat)
+			if w.columns[i].Category == TypeDate {
+				width = len(XLSXDateFormat)
+			}
+		default:
+			width = textWidth(fmt.Sprint(v))
+			if _, ok := v.(float64); ok && w.columns[i].Category == TypeTimeOfDay {
+				width = len(XLSXTimeOfDayFormat)
+			}
+		}
+		width += 2
+		if width > XLSXMaxWidth {
+			width = XLSXMaxWidth
+		}
+		if width > w.widths[i] {
+			w.widths[i] = width
+		}
+	}
+}
+
+// helper function to set column widths and write header and sampled records
+func (w *xlsxWriter) flushSample() error {
+	for i, width := range w.widths {
+		if err := w.stream.SetColWidth(i+1, i+1, float64(width)); err != nil {
+			return err
+		}
+	}
+	for _, cells := range w.sample {
+		if err := w.writeRow(cells); err != nil {
+			return err
+		}
+	}
+	// release sample memory, all further records are streamed
+	w.sample = nil
+	return nil
+}
+
+// helper function to write single row of cells
+func (w *xlsxWriter) writeRow(cells []interface{}) error {
+	w.row++
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.stream.SetRow(cell, cells)
+}
+
+// Close writes buffered records and saves the workbook
+func (w *xlsxWriter) Close() error {
+	defer w.file.Close()
+	if w.sample != nil {
+		if err := w.flushSample(); err != nil {
+			return err
+		}
+	}
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	return w.file.SaveAs(w.fname)
+}
+
+// helper function to convert DB value to typed xlsx cell value,
+// values which can't be converted are written as text
+func xlsxValue(val interface{}, col Column) interface{} {
+	if val == nil {
+		return nil
+	}
+	switch col.Category {
+	case TypeInteger:
+		if v, err := int64Value(val); err == nil {
+			return v
+		}
+	case TypeFloat, TypeDecimal:
+		if v, err := float64Value(columnValue(val, col)); err == nil {
+			return v
+		}
+	case TypeBool:
+		if v, err := boolValue(val); err == nil {
+			return v
+		}
+	case TypeDate:
+		if v, err := timeOf(val, DateFormat); err == nil {
+			return v
+		}
+	case TypeTime:
+		if v, err := timeOf(val, time.RFC3339Nano); err == nil {
+			return v
+		}
+	case TypeTimeOfDay:
+		// spreadsheet time is fraction of a day
+		if v, err := timeOfDay(val); err == nil {
+			return v.Hours() / 24
+		}
+	}
+	return stringValue(columnValue(val, col))
+}
@@ -0,0 +1,189 @@
+package main
+
+// formats module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// provides yaml, xml and insert output formats, e.g.
+// - yaml:   list of records
+//           - id: 1
+//             name: abc
+// - xml:    <results><row><id>1</id><name>abc</name></row></results>
+//           where root and row element names are set via set xmlroot/xmlrow
+// - insert: INSERT INTO table (id, name) VALUES (1, 'abc');
+//           where table name is set via set table=name or guessed from
+//           simple SELECT statement
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// XMLROOT represents name of root element of xml format
+var XMLROOT = "results"
+
+// XMLROW represents name of record element of xml format
+var XMLROW = "row"
+
+// INSERTTABLE represents table name used by insert format, if empty it is guessed
+var INSERTTABLE string
+
+// UnknownTable represents table name of insert format which can't be guessed
+const UnknownTable = "<table>"
+
+// tablePattern matches simple SELECT statement from single table
+var tablePattern = regexp.MustCompile(`(?is)^\s*select\s.+?\sfrom\s+([A-Za-z_][A-Za-z0-9_$.]*)\s*(?:(?:as\s+)?[A-Za-z_][A-Za-z0-9_]*\s*)?(?:where\s.*|order\s.*|limit\s.*|fetch\s.*|;)?\s*$`)
+
+// identPattern matches identifiers which do not require quoting
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// numberPattern matches decimal numbers which do not require quoting
+var numberPattern = regexp.MustCompile(`^[-+]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][-+]?[0-9]+)?$`)
+
+// xmlNamePattern matches characters which are not allowed in xml element names
+var xmlNamePattern = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// helper function to guess table name of simple SELECT statement
+func guessTable(stm string) string {
+	if m := tablePattern.FindStringSubmatch(stm); m != nil {
+		name := strings.ToLower(m[1])
+		if name != "where" && name != "order" && name != "limit" {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// helper function to return table name of insert format for given statement
+func insertTable(stm string) string {
+	if INSERTTABLE != "" {
+		return INSERTTABLE
+	}
+	if table := guessTable(stm); table != "" {
+		return table
+	}
+	return UnknownTable
+}
+
+// helper function to convert DB record to YAML list item
+func yamlRecord(rec Record) (string, error) {
+	// JSON representation keeps decimal precision and is valid YAML
+	data, err := json.Marshal(jsonValue(rec))
+	if err != nil {
+		return "", err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", err
+	}
+	if len(doc.Content) == 0 {
+		return "", fmt.Errorf("empty YAML document")
+	}
+	node := doc.Content[0]
+	blockStyle(node)
+	out, err := yaml.Marshal(&yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{node}})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// helper function to switch YAML nodes from JSON flow style to block style
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		blockStyle(n)
+	}
+}
+
+// helper function to convert column name to valid xml element name
+func xmlName(name string) string {
+	name = xmlNamePattern.ReplaceAllString(name, "_")
+	if name == "" || !identPattern.MatchString(name[:1]) {
+		name = "_" + name
+	}
+	return name
+}
+
+// helper function to convert DB record to xml element
+func xmlRecord(rec Record, keys []string) string {
+	var sb strings.Builder
+	sb.WriteString("  <" + xmlName(XMLROW) + ">\n")
+	for _, key := range keys {
+		name := xmlName(key)
+		val := rec[key]
+		if val == nil {
+			sb.WriteString(fmt.Sprintf("    <%s null=\"true\"/>\n", name))
+			continue
+		}
+		sb.WriteString("    <" + name + ">")
+		xml.EscapeText(&sb, []byte(formatValue(val)))
+		sb.WriteString("</" + name + ">\n")
+	}
+	sb.WriteString("  </" + xmlName(XMLROW) + ">")
+	return sb.String()
+}
+
+// helper function to convert raw scanned DB value of given column to SQL literal of current dialect
+func sqlLiteral(val interface{}, col Column) string {
+	if t, ok := val.(time.Time); ok {
+		return DIALECT.QuoteTime(t, col.Category)
+	}
+	switch v := columnValue(val, col).(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return DIALECT.QuoteBool(v)
+	case Decimal:
+		return string(v)
+	case Binary:
+		return DIALECT.QuoteBinary(v)
+	case []byte:
+		return DIALECT.QuoteBinary(v)
+	case JSONText:
+		return DIALECT.QuoteString(string(v))
+	case string:
+		// some drivers, e.g. MySQL, return numbers as text
+		switch col.Category {
+		case TypeInteger, TypeFloat, TypeDecimal:
+			if numberPattern.MatchString(v) {
+				return v
+			}
+		}
+		return DIALECT.QuoteString(v)
+	case float32, float64:
+		return fmt.Sprintf("%v", v)
+	}
+	switch reflect.ValueOf(val).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if col.Category == TypeBool {
+			// e.g. boolean columns stored as integers
+			return DIALECT.QuoteBool(fmt.Sprintf("%v", val) != "0")
+		}
+		return fmt.Sprintf("%v", val)
+	}
+	return DIALECT.QuoteString(formatValue(columnValue(val, col)))
+}
+
+// helper function to convert raw scanned DB values to INSERT statement,
+// values and columns are given in the same order
+func insertRecord(values []interface{}, columns []Column, table string) string {
+	cols := make([]string, len(columns))
+	vals := make([]string, len(columns))
+	for i, col := range columns {
+		name := col.Label
+		if name == "" {
+			name = col.Name
+		}
+		cols[i] = DIALECT.QuoteIdent(name)
+		vals[i] = sqlLiteral(values[i], col)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", table, strings.Join(cols, ", "), strings.Join(vals, ", "))
+}
@@ -0,0 +1,141 @@
+package main
+
+// highlight module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// highlight module colors edited input line when color output is enabled.
+// The line is split into tokens: keywords, strings, numbers, comments,
+// identifiers and other characters, and each token is printed with its own
+// color. Unterminated quotes and unbalanced parentheses are shown as errors.
+// Escape codes do not move the cursor, therefore the visible line content is
+// the same as of the line buffer.
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gookit/color"
+)
+
+// token kinds of highlighted line
+const (
+	tokenOther = iota
+	tokenKeyword
+	tokenString
+	tokenNumber
+	tokenComment
+	tokenIdent
+	tokenError
+)
+
+// HighlightStyles represents color styles of highlighted tokens
+var HighlightStyles = map[int]color.Style{
+	tokenKeyword: color.New(color.FgCyan, color.OpBold),
+	tokenString:  color.New(color.FgGreen),
+	tokenNumber:  color.New(color.FgMagenta),
+	tokenComment: color.New(color.FgGray),
+	tokenIdent:   color.New(color.FgYellow),
+	tokenError:   color.New(color.FgWhite, color.BgRed),
+}
+
+// token represents part of highlighted line
+type token struct {
+	text string
+	kind int
+}
+
+// helper function to split line into tokens
+func tokenize(line string) []token {
+	var tokens []token
+	var parens []int // indexes of tokens with open parentheses
+	runes := []rune(line)
+	for pos := 0; pos < len(runes); {
+		start := pos
+		kind := tokenOther
+		r := runes[pos]
+		switch {
+		case r == '-' && pos+1 < len(runes) && runes[pos+1] == '-':
+			// comment ends with new line of pasted text
+			for pos < len(runes) && runes[pos] != '\n' {
+				pos++
+			}
+			kind = tokenComment
+		case r == '/' && pos+1 < len(runes) && runes[pos+1] == '*':
+			kind = tokenComment
+			if idx := strings.Index(string(runes[pos+2:]), "*/"); idx >= 0 {
+				pos += 2 + len([]rune(string(runes[pos+2:])[:idx])) + 2
+			} else {
+				pos = len(runes)
+			}
+		case r == '\'' || r == '"' || r == '`':
+			// strings and quoted identifiers, doubled quote is escaped quote
+			kind = tokenString
+			if r != '\'' {
+				kind = tokenIdent
+			}
+			closed := false
+			for pos++; pos < len(runes); pos++ {
+				if runes[pos] == r {
+					if pos+1 < len(runes) && runes[pos+1] == r {
+						pos++
+						continue
+					}
+					pos++
+					closed = true
+					break
+				}
+			}
+			if !closed {
+				kind = tokenError
+			}
+		case unicode.IsDigit(r):
+			kind = tokenNumber
+			for pos < len(runes) && identRune(runes[pos]) {
+				pos++
+			}
+		case identRune(r):
+			for pos < len(runes) && identRune(runes[pos]) {
+				pos++
+			}
+			kind = tokenIdent
+			if isKeyword(string(runes[start:pos])) {
+				kind = tokenKeyword
+			}
+		case r == '(':
+			parens = append(parens, len(tokens))
+			pos++
+		case r == ')':
+			if len(parens) > 0 {
+				parens = parens[:len(parens)-1]
+			} else {
+				kind = tokenError
+			}
+			pos++
+		default:
+			for pos++; pos < len(runes); pos++ {
+				if r := runes[pos]; identRune(r) || strings.ContainsRune("-/'\"`()", r) {
+					break
+				}
+			}
+		}
+		tokens = append(tokens, token{text: string(runes[start:pos]), kind: kind})
+	}
+	// parentheses which are not closed
+	for _, idx := range parens {
+		tokens[idx].kind = tokenError
+	}
+	return tokens
+}
+
+// helper function to return line with colored SQL tokens
+func highlight(line string) string {
+	var out strings.Builder
+	for _, t := range tokenize(line) {
+		if style, ok := HighlightStyles[t.kind]; ok {
+			out.WriteString(style.Sprint(t.text))
+		} else {
+			out.WriteString(t.text)
+		}
+	}
+	return out.String()
+}
@@ -5,9 +5,11 @@ package main
 
 import (
 	"bufio"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func HistoryFile() string {
@@ -68,9 +70,111 @@ func FlushHistory(commands []string) {
 		if cmd == "" {
 			continue
 		}
-		_, err := file.WriteString(cmd + "\n")
+		// do not keep DB passwords in history file
+		_, err := file.WriteString(maskURI(cmd) + "\n")
 		if err != nil {
 			log.Fatal(err)
 		}
 	}
 }
+
+// helper function to add command to the history, the history keeps command with
+// masked DB passwords, e.g. to show it in search, and its raw form is kept in
+// given map to execute it again
+func addHistory(history []string, raw map[string]string, cmd string) []string {
+	masked := maskURI(cmd)
+	if masked != cmd {
+		raw[masked] = cmd
+	}
+	return append(history, masked)
+}
+
+// helper function to return raw form of history entry, i.e. with DB passwords
+func unmaskHistory(raw map[string]string, cmd string) string {
+	if c, ok := raw[cmd]; ok {
+		return c
+	}
+	return cmd
+}
+
+// historySearch represents state of reverse incremental history search (Ctrl-R)
+type historySearch struct {
+	query string // search text typed by the user
+	index int    // index of current match in history, -1 if nothing matches
+}
+
+// helper function to start reverse search over given history
+func newHistorySearch(history []string) *historySearch {
+	return &historySearch{index: len(history)}
+}
+
+// helper function to find most recent history entry at or before given index
+// which contains search text and differs from skip entry
+func (s *historySearch) find(history []string, from int, skip string) int {
+	if from >= len(history) {
+		from = len(history) - 1
+	}
+	for idx := from; idx >= 0; idx-- {
+		if history[idx] == skip {
+			continue
+		}
+		if strings.Contains(history[idx], s.query) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// Type adds text to search query and narrows current match
+func (s *historySearch) Type(history []string, text string) {
+	s.query += text
+	if s.index < 0 {
+		// search has already failed, longer query can't match either
+		return
+	}
+	// current match is kept if it still contains the query
+	s.index = s.find(history, s.index, "")
+}
+
+// Backspace removes last character of search query and searches again from most recent entry
+func (s *historySearch) Backspace(history []string) {
+	if s.query == "" {
+		return
+	}
+	runes := []rune(s.query)
+	s.query = string(runes[:len(runes)-1])
+	if s.query == "" {
+		s.index = len(history)
+		return
+	}
+	s.index = s.find(history, len(history), "")
+}
+
+// Next moves to older entry matching search query, the match is kept if there is none
+func (s *historySearch) Next(history []string) {
+	if s.index < 0 || s.index >= len(history) {
+		if s.query != "" {
+			s.index = s.find(history, len(history), "")
+		}
+		return
+	}
+	if idx := s.find(history, s.index-1, history[s.index]); idx >= 0 {
+		s.index = idx
+	}
+}
+
+// Match returns current matching history entry
+func (s *historySearch) Match(history []string) string {
+	if s.index < 0 || s.index >= len(history) {
+		return ""
+	}
+	return history[s.index]
+}
+
+// Prompt returns search prompt, e.g. (reverse-i-search)`sel':
+func (s *historySearch) Prompt() string {
+	if s.index < 0 {
+		return fmt.Sprintf("(failed reverse-i-search)`%s': ", s.query)
+	}
+	return fmt.Sprintf("(reverse-i-search)`%s': ", s.query)
+}
@@ -0,0 +1,36 @@
+package main
+
+// history module tests
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+
+import (
+	"testing"
+)
+
+// TestAddHistory tests that history keeps masked DB passwords and raw commands are restored
+func TestAddHistory(t *testing.T) {
+	tests := []struct {
+		cmd    string
+		masked string
+	}{
+		{"select * from t", "select * from t"},
+		{"connect postgres://u:secret@h/db", "connect postgres://u:****@h/db"},
+	}
+	var history []string
+	raw := make(map[string]string)
+	for _, tc := range tests {
+		history = addHistory(history, raw, tc.cmd)
+		entry := history[len(history)-1]
+		if entry != tc.masked {
+			t.Errorf("%s: expected history entry %s, got %s", tc.cmd, tc.masked, entry)
+		}
+		if cmd := unmaskHistory(raw, entry); cmd != tc.cmd {
+			t.Errorf("%s: expected raw command %s, got %s", tc.masked, tc.cmd, cmd)
+		}
+	}
+	search := newHistorySearch(history)
+	search.Type(history, "connect")
+	if match := search.Match(history); match != "connect postgres://u:****@h/db" {
+		t.Errorf("expected search match with masked password, got %s", match)
+	}
+}
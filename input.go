@@ -0,0 +1,285 @@
+package main
+
+// input module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// input module reads keys pressed in terminal and passes them to keyboard
+// handler. It replaces keyboard.Listen of atomicgo keyboard package which
+// reads terminal input by chunks and keeps only first key of a chunk which
+// starts with escape sequence. In bracketed paste mode terminal encloses
+// pasted text by ESC[200~ and ESC[201~ sequences, therefore the pasted text
+// would be lost. Here we parse escape sequences ourselves and pass pasted
+// text to keyboard handler as single key, such that new lines of multi-line
+// SQL statements are not treated as Enter. Terminal resize is passed to
+// keyboard handler as key too, such that input line is redrawn by the same
+// goroutine which edits it.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+
+	"atomicgo.dev/keyboard/keys"
+	"github.com/containerd/console"
+)
+
+// PasteModeOn represents terminal sequence which enables bracketed paste mode
+const PasteModeOn = "\x1b[?2004h"
+
+// PasteModeOff represents terminal sequence which disables bracketed paste mode
+const PasteModeOff = "\x1b[?2004l"
+
+// PasteStart represents terminal sequence which starts pasted text
+const PasteStart = "\x1b[200~"
+
+// PasteEnd represents terminal sequence which ends pasted text
+const PasteEnd = "\x1b[201~"
+
+// keyPaste represents code of key with pasted text in its runes, the value
+// does not clash with key codes of keys package
+const keyPaste keys.KeyCode = -1000
+
+// keyResize represents code of key sent when terminal is resized
+const keyResize keys.KeyCode = -1001
+
+// keySequences represents escape sequences of special keys
+var keySequences = map[string]keys.Key{
+	"\x1b[A":    {Code: keys.Up},
+	"\x1b[B":    {Code: keys.Down},
+	"\x1b[C":    {Code: keys.Right},
+	"\x1b[D":    {Code: keys.Left},
+	"\x1bOA":    {Code: keys.Up},
+	"\x1bOB":    {Code: keys.Down},
+	"\x1bOC":    {Code: keys.Right},
+	"\x1bOD":    {Code: keys.Left},
+	"\x1b[1;2A": {Code: keys.ShiftUp},
+	"\x1b[1;2B": {Code: keys.ShiftDown},
+	"\x1b[1;2C": {Code: keys.ShiftRight},
+	"\x1b[1;2D": {Code: keys.ShiftLeft},
+	"\x1b[1;3A": {Code: keys.Up, AltPressed: true},
+	"\x1b[1;3B": {Code: keys.Down, AltPressed: true},
+	"\x1b[1;3C": {Code: keys.Right, AltPressed: true},
+	"\x1b[1;3D": {Code: keys.Left, AltPressed: true},
+	"\x1b[1;5A": {Code: keys.CtrlUp},
+	"\x1b[1;5B": {Code: keys.CtrlDown},
+	"\x1b[1;5C": {Code: keys.CtrlRight},
+	"\x1b[1;5D": {Code: keys.CtrlLeft},
+	"\x1b[H":    {Code: keys.Home},
+	"\x1b[F":    {Code: keys.End},
+	"\x1bOH":    {Code: keys.Home},
+	"\x1bOF":    {Code: keys.End},
+	"\x1b[1~":   {Code: keys.Home},
+	"\x1b[4~":   {Code: keys.End},
+	"\x1b[7~":   {Code: keys.Home},
+	"\x1b[8~":   {Code: keys.End},
+	"\x1b[3~":   {Code: keys.Delete},
+	"\x1b[5~":   {Code: keys.PgUp},
+	"\x1b[6~":   {Code: keys.PgDown},
+	"\x1b[Z":    {Code: keys.ShiftTab},
+}
+
+// keyReader parses terminal input into keys
+type keyReader struct {
+	in  io.Reader
+	buf []byte // read but not yet parsed input
+}
+
+// helper function to read next chunk of input into the buffer
+func (r *keyReader) fill() error {
+	var chunk [256]byte
+	n, err := r.in.Read(chunk[:])
+	r.buf = append(r.buf, chunk[:n]...)
+	if n == 0 && err == nil {
+		err = io.EOF
+	}
+	if n > 0 {
+		return nil
+	}
+	return err
+}
+
+// Next returns next key of terminal input
+func (r *keyReader) Next() (keys.Key, error) {
+	for {
+		if len(r.buf) == 0 {
+			if err := r.fill(); err != nil {
+				return keys.Key{}, err
+			}
+		}
+		key, n, ok := parseKey(r.buf)
+		if n == 0 {
+			// incomplete escape sequence or character
+			if err := r.fill(); err != nil {
+				return keys.Key{}, err
+			}
+			continue
+		}
+		if string(r.buf[:n]) == PasteStart {
+			return r.paste()
+		}
+		r.buf = r.buf[n:]
+		if ok {
+			return key, nil
+		}
+	}
+}
+
+// helper function to read pasted text up to the end of paste sequence
+func (r *keyReader) paste() (keys.Key, error) {
+	r.buf = r.buf[len(PasteStart):]
+	for {
+		if idx := bytes.Index(r.buf, []byte(PasteEnd)); idx >= 0 {
+			text := string(r.buf[:idx])
+			r.buf = r.buf[idx+len(PasteEnd):]
+			return keys.Key{Code: keyPaste, Runes: []rune(text)}, nil
+		}
+		if err := r.fill(); err != nil {
+			return keys.Key{}, err
+		}
+	}
+}
+
+// helper function to parse key at the beginning of the buffer, it returns
+// the key, number of its bytes (zero if more input is required) and false
+// for unknown escape sequences and invalid characters
+func parseKey(buf []byte) (keys.Key, int, bool) {
+	b := buf[0]
+	if b == 0x1b {
+		if len(buf) == 1 {
+			return keys.Key{Code: keys.Escape}, 1, true
+		}
+		switch buf[1] {
+		case '[':
+			// control sequence ends with byte in 0x40-0x7e range
+			for i := 2; i < len(buf); i++ {
+				if buf[i] >= 0x40 && buf[i] <= 0x7e {
+					key, ok := keySequences[string(buf[:i+1])]
+					return key, i + 1, ok
+				}
+			}
+			return keys.Key{}, 0, false
+		case 'O':
+			if len(buf) < 3 {
+				return keys.Key{}, 0, false
+			}
+			key, ok := keySequences[string(buf[:3])]
+			return key, 3, ok
+		}
+		// Alt modifies next key, e.g. Alt-B or Alt-Backspace
+		key, n, ok := parseKey(buf[1:])
+		if n == 0 {
+			return key, 0, false
+		}
+		if key.Code == keys.RuneKey && len(key.Runes) > 1 {
+			key.Runes = key.Runes[:1]
+			n = utf8.RuneLen(key.Runes[0])
+		}
+		key.AltPressed = true
+		return key, n + 1, ok
+	}
+	if b < 0x20 || b == 0x7f {
+		return keys.Key{Code: keys.KeyCode(b)}, 1, true
+	}
+	if b == ' ' && (len(buf) == 1 || buf[1] < 0x20 || buf[1] == 0x7f) {
+		return keys.Key{Code: keys.Space, Runes: []rune{' '}}, 1, true
+	}
+	// consecutive characters are passed as single key like keyboard package does
+	var runes []rune
+	var n int
+	for n < len(buf) && buf[n] >= 0x20 && buf[n] != 0x7f {
+		if !utf8.FullRune(buf[n:]) {
+			break
+		}
+		r, size := utf8.DecodeRune(buf[n:])
+		if r == utf8.RuneError && size == 1 {
+			if len(runes) == 0 {
+				return keys.Key{}, 1, false
+			}
+			break
+		}
+		runes = append(runes, r)
+		n += size
+	}
+	if len(runes) == 0 {
+		return keys.Key{}, 0, false
+	}
+	return keys.Key{Code: keys.RuneKey, Runes: runes}, n, true
+}
+
+// helper function to return pasted text with unix new lines and without trailing new lines
+func pastedText(key keys.Key) string {
+	text := strings.ReplaceAll(string(key.Runes), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	return strings.TrimRight(text, "\n")
+}
+
+// helper function to call onKeyPress for every key pressed in terminal until
+// it returns stop, meanwhile terminal is kept in raw and bracketed paste modes
+func listenKeys(onKeyPress func(key keys.Key) (stop bool, err error)) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+	// keys are read from tty, therefore it is switched into raw mode even when
+	// stdin is redirected
+	if con, err := console.ConsoleFromFile(tty); err == nil {
+		if err := con.SetRaw(); err != nil {
+			return fmt.Errorf("failed to set raw mode: %w", err)
+		}
+		defer con.Reset()
+	}
+	// use tty since handler may reset stdout before it stops, see Ctrl-Q
+	fmt.Fprint(tty, PasteModeOn)
+	defer fmt.Fprint(tty, PasteModeOff)
+
+	// keys are read by separate goroutine which waits until handler processes
+	// the key, e.g. external editor started by handler reads terminal itself
+	keyCh := make(chan keys.Key)
+	errCh := make(chan error, 1)
+	nextCh := make(chan bool)
+	go func() {
+		reader := &keyReader{in: tty}
+		for {
+			key, err := reader.Next()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			keyCh <- key
+			if !<-nextCh {
+				return
+			}
+		}
+	}()
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+
+	for {
+		select {
+		case err := <-errCh:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case <-winch:
+			if stop, err := onKeyPress(keys.Key{Code: keyResize}); stop || err != nil {
+				return err
+			}
+		case key := <-keyCh:
+			stop, err := onKeyPress(key)
+			if stop || err != nil {
+				nextCh <- false
+				return err
+			}
+			nextCh <- true
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+// introspection module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// provides tables, describe and explain commands which hide differences
+// between DB backends
+
+import (
+	"errors"
+	"strings"
+)
+
+// helper function to list tables of current DB
+func showTables() error {
+	if DB == nil {
+		return errors.New("no active DB connection, please use connect command")
+	}
+	return executeRange(DIALECT.TablesQuery(), 0, 0)
+}
+
+// helper function to describe given table of current DB
+func describeTable(table string) error {
+	if DB == nil {
+		return errors.New("no active DB connection, please use connect command")
+	}
+	table = strings.Trim(table, " ;")
+	if table == "" {
+		return errors.New("usage: describe <table>")
+	}
+	return executeRange(DIALECT.DescribeQuery(), 0, 0, table)
+}
+
+// helper function to show query plan of given statement
+func explainStatement(stm string) error {
+	if DB == nil {
+		return errors.New("no active DB connection, please use connect command")
+	}
+	stm = strings.Trim(stm, " ;")
+	if stm == "" {
+		return errors.New("usage: explain <sql statement>")
+	}
+	// all statements run in the same transaction, i.e. DB session, since
+	// query plan may be stored by one statement and read by another one
+	stms := DIALECT.Explain(stm)
+	return executeSteps(stms[:len(stms)-1], stms[len(stms)-1], 0, 0)
+}
@@ -0,0 +1,387 @@
+package main
+
+// line buffer module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// LineBuffer implements readline (emacs) and vi style editing of input line.
+// It only keeps the text and cursor position and does not perform any
+// terminal I/O, the keyboard handler maps keys to buffer operations and
+// redraws the line afterwards. Cursor moves by grapheme clusters, e.g. a
+// letter with combining accent or emoji sequence is a single character.
+
+import (
+	"unicode"
+
+	"github.com/rivo/uniseg"
+)
+
+// UndoLimit represents maximum number of saved line states
+const UndoLimit = 100
+
+// LineBuffer represents edited input line and cursor position within it
+type LineBuffer struct {
+	runes []rune      // line content
+	pos   int         // cursor position, from 0 to len(runes)
+	kill  string      // last killed text, see Yank
+	undo  []lineState // saved line states, see Save and Undo
+}
+
+// lineState represents saved content of line buffer
+type lineState struct {
+	runes []rune
+	pos   int
+}
+
+// String returns line content
+func (b *LineBuffer) String() string {
+	return string(b.runes)
+}
+
+// Len returns number of characters in the line
+func (b *LineBuffer) Len() int {
+	return len(b.runes)
+}
+
+// Pos returns cursor position
+func (b *LineBuffer) Pos() int {
+	return b.pos
+}
+
+// Set replaces line content and moves cursor to its end
+func (b *LineBuffer) Set(line string) {
+	b.runes = []rune(line)
+	b.pos = len(b.runes)
+}
+
+// Reset clears the line and its undo states, the kill buffer is kept
+func (b *LineBuffer) Reset() {
+	b.runes = nil
+	b.pos = 0
+	b.undo = nil
+}
+
+// Save saves current line state which can be restored by Undo
+func (b *LineBuffer) Save() {
+	if len(b.undo) >= UndoLimit {
+		b.undo = b.undo[1:]
+	}
+	b.undo = append(b.undo, lineState{runes: append([]rune(nil), b.runes...), pos: b.pos})
+}
+
+// Undo restores last saved line state
+func (b *LineBuffer) Undo() {
+	if len(b.undo) == 0 {
+		return
+	}
+	state := b.undo[len(b.undo)-1]
+	b.undo = b.undo[:len(b.undo)-1]
+	b.runes, b.pos = state.runes, state.pos
+}
+
+// Insert inserts text at cursor position
+func (b *LineBuffer) Insert(text string) {
+	ins := []rune(text)
+	runes := make([]rune, 0, len(b.runes)+len(ins))
+	runes = append(runes, b.runes[:b.pos]...)
+	runes = append(runes, ins...)
+	runes = append(runes, b.runes[b.pos:]...)
+	b.runes = runes
+	b.pos += len(ins)
+}
+
+// Replace replaces text between given position and cursor, e.g. completed word
+func (b *LineBuffer) Replace(start int, text string) {
+	if start < 0 || start > b.pos {
+		return
+	}
+	b.remove(start, b.pos)
+	b.Insert(text)
+}
+
+// helper function to remove characters between from and to positions and return them
+func (b *LineBuffer) remove(from, to int) string {
+	if from < 0 {
+		from = 0
+	}
+	if to > len(b.runes) {
+		to = len(b.runes)
+	}
+	if from >= to {
+		return ""
+	}
+	text := string(b.runes[from:to])
+	b.runes = append(b.runes[:from], b.runes[to:]...)
+	if b.pos > to {
+		b.pos -= to - from
+	} else if b.pos > from {
+		b.pos = from
+	}
+	return text
+}
+
+// helper function to remove characters between from and to positions into kill buffer
+func (b *LineBuffer) killRange(from, to int) {
+	if text := b.remove(from, to); text != "" {
+		b.kill = text
+	}
+}
+
+// helper function to return positions of grapheme cluster boundaries of the line
+func (b *LineBuffer) boundaries() []int {
+	bounds := []int{0}
+	var pos int
+	g := uniseg.NewGraphemes(string(b.runes))
+	for g.Next() {
+		pos += len(g.Runes())
+		bounds = append(bounds, pos)
+	}
+	return bounds
+}
+
+// helper function to return beginning of grapheme cluster before given position
+func (b *LineBuffer) prevChar(pos int) int {
+	prev := 0
+	for _, bound := range b.boundaries() {
+		if bound >= pos {
+			break
+		}
+		prev = bound
+	}
+	return prev
+}
+
+// helper function to return end of grapheme cluster after given position
+func (b *LineBuffer) nextChar(pos int) int {
+	for _, bound := range b.boundaries() {
+		if bound > pos {
+			return bound
+		}
+	}
+	return len(b.runes)
+}
+
+// Backspace deletes character before cursor
+func (b *LineBuffer) Backspace() {
+	b.remove(b.prevChar(b.pos), b.pos)
+}
+
+// Delete deletes character under cursor
+func (b *LineBuffer) Delete() {
+	b.remove(b.pos, b.nextChar(b.pos))
+}
+
+// Left moves cursor one character left
+func (b *LineBuffer) Left() {
+	b.pos = b.prevChar(b.pos)
+}
+
+// Right moves cursor one character right
+func (b *LineBuffer) Right() {
+	b.pos = b.nextChar(b.pos)
+}
+
+// Home moves cursor to the beginning of the line
+func (b *LineBuffer) Home() {
+	b.pos = 0
+}
+
+// End moves cursor to the end of the line
+func (b *LineBuffer) End() {
+	b.pos = len(b.runes)
+}
+
+// helper function to check if character is part of a word
+func wordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// helper function to return position of beginning of the word before cursor
+func (b *LineBuffer) wordStart() int {
+	pos := b.pos
+	for pos > 0 && !wordRune(b.runes[pos-1]) {
+		pos--
+	}
+	for pos > 0 && wordRune(b.runes[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+// helper function to return position of end of the word after cursor
+func (b *LineBuffer) wordEnd() int {
+	pos := b.pos
+	for pos < len(b.runes) && !wordRune(b.runes[pos]) {
+		pos++
+	}
+	for pos < len(b.runes) && wordRune(b.runes[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// WordLeft moves cursor to the beginning of previous word (Alt-B)
+func (b *LineBuffer) WordLeft() {
+	b.pos = b.wordStart()
+}
+
+// WordRight moves cursor to the end of next word (Alt-F)
+func (b *LineBuffer) WordRight() {
+	b.pos = b.wordEnd()
+}
+
+// KillWordBackward kills whitespace delimited word before cursor (Ctrl-W)
+func (b *LineBuffer) KillWordBackward() {
+	pos := b.pos
+	for pos > 0 && unicode.IsSpace(b.runes[pos-1]) {
+		pos--
+	}
+	for pos > 0 && !unicode.IsSpace(b.runes[pos-1]) {
+		pos--
+	}
+	b.killRange(pos, b.pos)
+}
+
+// KillWordForward kills text up to the end of next word (Alt-D)
+func (b *LineBuffer) KillWordForward() {
+	b.killRange(b.pos, b.wordEnd())
+}
+
+// KillPrevWord kills text from the beginning of previous word to the cursor (Alt-Backspace)
+func (b *LineBuffer) KillPrevWord() {
+	b.killRange(b.wordStart(), b.pos)
+}
+
+// KillToEnd kills text from cursor to the end of the line (Ctrl-K)
+func (b *LineBuffer) KillToEnd() {
+	b.killRange(b.pos, len(b.runes))
+}
+
+// KillToStart kills text from the beginning of the line to the cursor (Ctrl-U)
+func (b *LineBuffer) KillToStart() {
+	b.killRange(0, b.pos)
+}
+
+// Yank inserts last killed text at cursor position (Ctrl-Y)
+func (b *LineBuffer) Yank() {
+	b.Insert(b.kill)
+}
+
+// Transpose swaps character before cursor with character under cursor and
+// moves cursor forward, at the end of the line two last characters are swapped (Ctrl-T)
+func (b *LineBuffer) Transpose() {
+	if len(b.runes) < 2 || b.pos == 0 {
+		return
+	}
+	// at the end of line two last characters are swapped
+	mid := b.pos
+	if mid == len(b.runes) {
+		mid = b.prevChar(mid)
+	}
+	start, end := b.prevChar(mid), b.nextChar(mid)
+	if start == mid || end == mid {
+		return
+	}
+	swapped := append(append([]rune{}, b.runes[mid:end]...), b.runes[start:mid]...)
+	copy(b.runes[start:end], swapped)
+	b.pos = end
+}
+
+// helper function to return vi character class: 0 for blanks, 1 for word
+// characters and 2 for other characters
+func viClass(r rune) int {
+	if unicode.IsSpace(r) {
+		return 0
+	}
+	if wordRune(r) {
+		return 1
+	}
+	return 2
+}
+
+// helper function to return position of beginning of next vi word
+func (b *LineBuffer) viWordNext() int {
+	pos := b.pos
+	if pos < len(b.runes) {
+		if class := viClass(b.runes[pos]); class != 0 {
+			for pos < len(b.runes) && viClass(b.runes[pos]) == class {
+				pos++
+			}
+		}
+	}
+	for pos < len(b.runes) && viClass(b.runes[pos]) == 0 {
+		pos++
+	}
+	return pos
+}
+
+// helper function to return position of last character of current or next vi word
+func (b *LineBuffer) viWordEnd() int {
+	pos := b.pos + 1
+	for pos < len(b.runes) && viClass(b.runes[pos]) == 0 {
+		pos++
+	}
+	if pos >= len(b.runes) {
+		return len(b.runes) - 1
+	}
+	class := viClass(b.runes[pos])
+	for pos+1 < len(b.runes) && viClass(b.runes[pos+1]) == class {
+		pos++
+	}
+	return pos
+}
+
+// ViWordNext moves cursor to the beginning of next word (vi w)
+func (b *LineBuffer) ViWordNext() {
+	b.pos = b.viWordNext()
+}
+
+// ViWordEnd moves cursor to the end of word (vi e)
+func (b *LineBuffer) ViWordEnd() {
+	if pos := b.viWordEnd(); pos > b.pos {
+		b.pos = pos
+	}
+}
+
+// ViWordPrev moves cursor to the beginning of previous word (vi b)
+func (b *LineBuffer) ViWordPrev() {
+	pos := b.pos
+	for pos > 0 && viClass(b.runes[pos-1]) == 0 {
+		pos--
+	}
+	if pos > 0 {
+		class := viClass(b.runes[pos-1])
+		for pos > 0 && viClass(b.runes[pos-1]) == class {
+			pos--
+		}
+	}
+	b.pos = pos
+}
+
+// ViDeleteWord deletes text up to the beginning of next word (vi dw)
+func (b *LineBuffer) ViDeleteWord() {
+	b.killRange(b.pos, b.viWordNext())
+}
+
+// ViChangeWord deletes text up to the end of word (vi cw)
+func (b *LineBuffer) ViChangeWord() {
+	if b.pos >= len(b.runes) {
+		return
+	}
+	end := b.pos + 1
+	if viClass(b.runes[b.pos]) != 0 {
+		// cw on non-blank acts like ce and keeps blanks after the word
+		end = b.pos
+		class := viClass(b.runes[end])
+		for end < len(b.runes) && viClass(b.runes[end]) == class {
+			end++
+		}
+	}
+	b.killRange(b.pos, end)
+}
+
+// ViClamp keeps cursor on last character of the line as vi normal mode requires
+func (b *LineBuffer) ViClamp() {
+	if b.pos >= len(b.runes) && len(b.runes) > 0 {
+		b.pos = b.prevChar(len(b.runes))
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+// line buffer module tests
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+
+import (
+	"testing"
+)
+
+// TestLineBufferEdit tests cursor movement, insertion and deletion of characters
+func TestLineBufferEdit(t *testing.T) {
+	tests := []struct {
+		line    string
+		pos     int
+		op      func(b *LineBuffer)
+		want    string
+		wantPos int
+	}{
+		{"sel", 3, func(b *LineBuffer) { b.Insert("ect") }, "select", 6},
+		{"sect", 2, func(b *LineBuffer) { b.Insert("le") }, "select", 4},
+		{"select * fr", 11, func(b *LineBuffer) { b.Replace(9, "from") }, "select * from", 13},
+		{"abc", 2, func(b *LineBuffer) { b.Backspace() }, "ac", 1},
+		{"abc", 0, func(b *LineBuffer) { b.Backspace() }, "abc", 0},
+		{"abc", 1, func(b *LineBuffer) { b.Delete() }, "ac", 1},
+		{"abc", 3, func(b *LineBuffer) { b.Delete() }, "abc", 3},
+		{"abc", 2, func(b *LineBuffer) { b.Left() }, "abc", 1},
+		{"abc", 0, func(b *LineBuffer) { b.Left() }, "abc", 0},
+		{"abc", 2, func(b *LineBuffer) { b.Right() }, "abc", 3},
+		{"abc", 3, func(b *LineBuffer) { b.Right() }, "abc", 3},
+		{"abc", 2, func(b *LineBuffer) { b.Home() }, "abc", 0},
+		{"abc", 1, func(b *LineBuffer) { b.End() }, "abc", 3},
+		{"abcd", 2, func(b *LineBuffer) { b.Transpose() }, "acbd", 3},
+		{"abcd", 4, func(b *LineBuffer) { b.Transpose() }, "abdc", 4},
+		{"abcd", 0, func(b *LineBuffer) { b.Transpose() }, "abcd", 0},
+	}
+	for _, tc := range tests {
+		b := &LineBuffer{}
+		b.Set(tc.line)
+		b.pos = tc.pos
+		tc.op(b)
+		if b.String() != tc.want || b.Pos() != tc.wantPos {
+			t.Errorf("%q at %d: expected %q at %d, got %q at %d", tc.line, tc.pos, tc.want, tc.wantPos, b.String(), b.Pos())
+		}
+	}
+}
+
+// TestLineBufferKill tests kill and yank operations
+func TestLineBufferKill(t *testing.T) {
+	tests := []struct {
+		line    string
+		pos     int
+		op      func(b *LineBuffer)
+		want    string
+		wantPos int
+	}{
+		{"select * from t", 7, func(b *LineBuffer) { b.KillToEnd() }, "select ", 7},
+		{"select * from t", 7, func(b *LineBuffer) { b.KillToStart() }, "* from t", 0},
+		{"select a.id, ", 13, func(b *LineBuffer) { b.KillWordBackward() }, "select ", 7},
+		{"select a.id", 11, func(b *LineBuffer) { b.KillPrevWord() }, "select a.", 9},
+		{"select a.id", 6, func(b *LineBuffer) { b.KillWordForward() }, "select.id", 6},
+		{"select * from t", 7, func(b *LineBuffer) {
+			b.KillToEnd()
+			b.Home()
+			b.Yank()
+		}, "* from tselect ", 8},
+		// killing empty text keeps previously killed one
+		{"select x", 7, func(b *LineBuffer) {
+			b.KillToEnd()
+			b.KillToEnd()
+			b.Yank()
+		}, "select x", 8},
+	}
+	for _, tc := range tests {
+		b := &LineBuffer{}
+		b.Set(tc.line)
+		b.pos = tc.pos
+		tc.op(b)
+		if b.String() != tc.want || b.Pos() != tc.wantPos {
+			t.Errorf("%q at %d: expected %q at %d, got %q at %d", tc.line, tc.pos, tc.want, tc.wantPos, b.String(), b.Pos())
+		}
+	}
+}
+
+// TestLineBufferUndo tests undo of saved states
+func TestLineBufferUndo(t *testing.T) {
+	b := &LineBuffer{}
+	b.Set("select")
+	b.Save()
+	b.Insert(" *")
+	b.Save()
+	b.KillToStart()
+	b.Undo()
+	if b.String() != "select *" || b.Pos() != 8 {
+		t.Fatalf("expected %q at 8, got %q at %d", "select *", b.String(), b.Pos())
+	}
+	b.Undo()
+	if b.String() != "select" || b.Pos() != 6 {
+		t.Fatalf("expected %q at 6, got %q at %d", "select", b.String(), b.Pos())
+	}
+	// no more states to restore
+	b.Undo()
+	if b.String() != "select" || b.Pos() != 6 {
+		t.Fatalf("expected %q at 6, got %q at %d", "select", b.String(), b.Pos())
+	}
+	for i := 0; i < UndoLimit+10; i++ {
+		b.Save()
+	}
+	if len(b.undo) != UndoLimit {
+		t.Fatalf("expected %d undo states, got %d", UndoLimit, len(b.undo))
+	}
+	b.Reset()
+	if b.Len() != 0 || len(b.undo) != 0 {
+		t.Fatalf("line is not reset: %q", b.String())
+	}
+}
+
+// TestLineBufferWords tests emacs word motions
+func TestLineBufferWords(t *testing.T) {
+	tests := []struct {
+		line    string
+		pos     int
+		left    bool // move to the left, otherwise to the right
+		wantPos int
+	}{
+		{"select a_b.id", 13, true, 11},
+		{"select a_b.id", 11, true, 7},
+		{"select", 0, true, 0},
+		{"select a_b.id", 0, false, 6},
+		{"select a_b.id", 10, false, 13},
+		{"select", 6, false, 6},
+	}
+	for _, tc := range tests {
+		b := &LineBuffer{}
+		b.Set(tc.line)
+		b.pos = tc.pos
+		if tc.left {
+			b.WordLeft()
+		} else {
+			b.WordRight()
+		}
+		if b.Pos() != tc.wantPos {
+			t.Errorf("%q at %d: expected position %d, got %d", tc.line, tc.pos, tc.wantPos, b.Pos())
+		}
+	}
+}
+
+// TestLineBufferVi tests vi motions and editing commands
+func TestLineBufferVi(t *testing.T) {
+	tests := []struct {
+		line    string
+		pos     int
+		op      func(b *LineBuffer)
+		want    string
+		wantPos int
+	}{
+		{"select a.id from t", 0, func(b *LineBuffer) { b.ViWordNext() }, "select a.id from t", 7},
+		{"select a.id from t", 7, func(b *LineBuffer) { b.ViWordNext() }, "select a.id from t", 8},
+		{"select t", 7, func(b *LineBuffer) { b.ViWordNext() }, "select t", 8},
+		{"select a.id", 0, func(b *LineBuffer) { b.ViWordEnd() }, "select a.id", 5},
+		{"select a.id", 5, func(b *LineBuffer) { b.ViWordEnd() }, "select a.id", 7},
+		{"select a.id", 10, func(b *LineBuffer) { b.ViWordPrev() }, "select a.id", 9},
+		{"select  a", 8, func(b *LineBuffer) { b.ViWordPrev() }, "select  a", 0},
+		{"select a.id from t", 7, func(b *LineBuffer) { b.ViDeleteWord() }, "select .id from t", 7},
+		{"select  a", 6, func(b *LineBuffer) { b.ViDeleteWord() }, "selecta", 6},
+		{"select abc  from t", 7, func(b *LineBuffer) { b.ViChangeWord() }, "select   from t", 7},
+		{"select from", 6, func(b *LineBuffer) { b.ViChangeWord() }, "selectfrom", 6},
+		{"select", 6, func(b *LineBuffer) { b.ViClamp() }, "select", 5},
+		{"", 0, func(b *LineBuffer) { b.ViClamp() }, "", 0},
+	}
+	for _, tc := range tests {
+		b := &LineBuffer{}
+		b.Set(tc.line)
+		b.pos = tc.pos
+		tc.op(b)
+		if b.String() != tc.want || b.Pos() != tc.wantPos {
+			t.Errorf("%q at %d: expected %q at %d, got %q at %d", tc.line, tc.pos, tc.want, tc.wantPos, b.String(), b.Pos())
+		}
+	}
+}
+
+// TestLineBufferGraphemes tests that cursor moves by grapheme clusters
+func TestLineBufferGraphemes(t *testing.T) {
+	// e with combining acute accent and family emoji sequence
+	accent := "e\u0301"
+	family := "\U0001F468\u200d\U0001F469\u200d\U0001F467"
+	tests := []struct {
+		line    string
+		pos     int
+		op      func(b *LineBuffer)
+		want    string
+		wantPos int
+	}{
+		{"caf" + accent, 5, func(b *LineBuffer) { b.Left() }, "caf" + accent, 3},
+		{"caf" + accent, 3, func(b *LineBuffer) { b.Right() }, "caf" + accent, 5},
+		{"a" + family + "b", 6, func(b *LineBuffer) { b.Backspace() }, "ab", 1},
+		{"a" + family + "b", 1, func(b *LineBuffer) { b.Delete() }, "ab", 1},
+		{"日本語", 2, func(b *LineBuffer) { b.Backspace() }, "日語", 1},
+		{"a" + accent + "b", 3, func(b *LineBuffer) { b.Transpose() }, "ab" + accent, 4},
+		{"x" + family + accent, 8, func(b *LineBuffer) { b.Transpose() }, "x" + accent + family, 8},
+		{"caf" + accent, 5, func(b *LineBuffer) { b.ViClamp() }, "caf" + accent, 3},
+	}
+	for _, tc := range tests {
+		b := &LineBuffer{}
+		b.Set(tc.line)
+		b.pos = tc.pos
+		tc.op(b)
+		if b.String() != tc.want || b.Pos() != tc.wantPos {
+			t.Errorf("%q at %d: expected %q at %d, got %q at %d", tc.line, tc.pos, tc.want, tc.wantPos, b.String(), b.Pos())
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+// profiles module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// connection profiles are read from ~/.config/sqlshell/connections.(json|yaml|toml)
+// file which holds named DB profiles, e.g. in YAML format
+//
+//   include:
+//     - common.yaml
+//   profiles:
+//     prod:
+//       type: postgres
+//       host: db.example.com
+//       port: 5432
+//       user: bla
+//       password_command: pass show db/prod
+//       database: db
+//       options:
+//         sslmode: require
+//       format: rows
+//       limit: 100
+//       prompt: "prod > "
+//       color: true
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Profile represents named DB connection profile
+type Profile struct {
+	DBConfig `yaml:",inline"`
+	Format   string `json:"format" yaml:"format" toml:"format"` // output DB format
+	Limit    *int   `json:"limit" yaml:"limit" toml:"limit"`    // limit of DB records to show
+	Prompt   string `json:"prompt" yaml:"prompt" toml:"prompt"` // shell prompt label
+	Color    *bool  `json:"color" yaml:"color" toml:"color"`    // use color output
+}
+
+// ProfilesConfig represents content of connection profiles file
+type ProfilesConfig struct {
+	Include  []string           `json:"include" yaml:"include" toml:"include"`    // list of files to include
+	Profiles map[string]Profile `json:"profiles" yaml:"profiles" toml:"profiles"` // named profiles
+}
+
+// ProfileFormats represents supported formats of connection profiles file
+var ProfileFormats = []string{"json", "yaml", "yml", "toml"}
+
+// envPattern represents environment variable pattern, e.g. ${PGPASSWORD}
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// helper function to return location of sqlshell configuration area
+func configDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "sqlshell")
+	}
+	udir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(udir, ".config", "sqlshell")
+}
+
+// helper function to find connection profiles file
+func profilesFile() (string, error) {
+	dir := configDir()
+	for _, ext := range ProfileFormats {
+		fname := filepath.Join(dir, fmt.Sprintf("connections.%s", ext))
+		if _, err := os.Stat(fname); err == nil {
+			return fname, nil
+		}
+	}
+	return "", fmt.Errorf("no connections.(json|yaml|toml) file found in %s", dir)
+}
+
+// helper function to interpolate environment variables in given value
+func interpolateEnv(val string) string {
+	return envPattern.ReplaceAllStringFunc(val, func(m string) string {
+		key := envPattern.FindStringSubmatch(m)[1]
+		return os.Getenv(key)
+	})
+}
+
+// helper function to interpolate environment variables in string fields of
+// parsed profile, i.e. values of variables can't break syntax of profiles file
+func (p *Profile) interpolateEnv() {
+	for _, field := range []*string{
+		&p.DBType, &p.User, &p.Password, &p.DBName, &p.Database, &p.DBFile, &p.Host,
+		(*string)(&p.Port), &p.PasswordCommand, &p.PassFile, &p.Format, &p.Prompt,
+	} {
+		*field = interpolateEnv(*field)
+	}
+	if p.Options != nil {
+		options := make(map[string]string, len(p.Options))
+		for key, val := range p.Options {
+			options[key] = interpolateEnv(val)
+		}
+		p.Options = options
+	}
+}
+
+// helper function to read profiles from given file and all its includes
+func readProfiles(fname string, seen map[string]bool) (map[string]Profile, error) {
+	path, err := filepath.Abs(fname)
+	if err != nil {
+		return nil, err
+	}
+	if seen[path] {
+		return nil, fmt.Errorf("circular include of %s", path)
+	}
+	seen[path] = true
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config ProfilesConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &config)
+	case ".toml":
+		err = toml.Unmarshal(data, &config)
+	default:
+		err = json.Unmarshal(data, &config)
+	}
+	if err != nil {
+		return nil, Error(err, UnmarshalErrorCode, fmt.Sprintf("unable to parse %s", path), "readProfiles")
+	}
+
+	// read included files first so that profiles of this file take precedence
+	profiles := make(map[string]Profile)
+	for _, inc := range config.Include {
+		inc = interpolateEnv(inc)
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(path), inc)
+		}
+		incProfiles, err := readProfiles(inc, seen)
+		if err != nil {
+			return nil, err
+		}
+		for name, p := range incProfiles {
+			profiles[name] = p
+		}
+	}
+	for name, p := range config.Profiles {
+		p.interpolateEnv()
+		profiles[name] = p
+	}
+	return profiles, nil
+}
+
+// helper function to load all connection profiles
+func loadProfiles() (map[string]Profile, error) {
+	fname, err := profilesFile()
+	if err != nil {
+		return nil, err
+	}
+	return readProfiles(fname, make(map[string]bool))
+}
+
+// helper function to find connection profile for given name, e.g. @prod
+func findProfile(name string) (Profile, error) {
+	name = strings.TrimPrefix(name, "@")
+	profiles, err := loadProfiles()
+	if err != nil {
+		return Profile{}, err
+	}
+	p, ok := profiles[name]
+	if !ok {
+		var names []string
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return p, fmt.Errorf("unknown profile '%s', available profiles: %s", name, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// helper function to connect to DB using given profile name, e.g. @prod
+func profileConnect(name string) error {
+	name = strings.TrimPrefix(name, "@")
+	if name == "" {
+		return errors.New("empty profile name")
+	}
+	p, err := findProfile(name)
+	if err != nil {
+		return err
+	}
+	if err := p.resolvePassword(); err != nil {
+		return err
+	}
+	dburi, err := p.DBUri()
+	if err != nil {
+		return err
+	}
+	if err := dbConnect(name, dburi, p.Prompt); err != nil {
+		return err
+	}
+	// apply profile settings to the new session, they are kept by the
+	// connection and restored when we switch to it via use command
+	if p.Limit != nil {
+		LIMIT = *p.Limit
+	}
+	if p.Format != "" {
+		setDBFormat(p.Format)
+	}
+	if p.Color != nil {
+		COLOR = *p.Color
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+// pushdown module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// By default index and limit settings are applied on client side, i.e. sqlshell
+// fetches DB rows and skips ones outside of index-limit range. In pushdown mode
+// SELECT statements are rewritten by the dialect of current DB connection, e.g.
+// with LIMIT/OFFSET clauses or ROWNUM wrapping for ORACLE, such that DB only
+// returns requested rows.
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PUSHDOWN defines if index and limit settings should be pushed down to DB
+var PUSHDOWN bool
+
+// FETCHFIRST defines if ORACLE should use FETCH FIRST syntax (12c+) instead of ROWNUM wrapping
+var FETCHFIRST bool
+
+// SHOWSQL defines if rewritten SQL statements should be printed
+var SHOWSQL bool
+
+// RowNumColumn represents auxiliary row number column of ROWNUM wrapping, it is not printed
+const RowNumColumn = "sqlsh_rn"
+
+// limitPattern matches clauses which already restrict number of returned rows
+var limitPattern = regexp.MustCompile(`(?i)\b(limit|offset|fetch\s+(first|next)|rownum|top)\b`)
+
+// lockPattern matches locking clauses, e.g. FOR UPDATE, which can't be combined with LIMIT or ROWNUM wrapping
+var lockPattern = regexp.MustCompile(`(?i)\b(for\s+(update|share|no\s+key\s+update|key\s+share)|lock\s+in\s+share\s+mode)\b`)
+
+// helper function to strip trailing comments and semicolons of statement,
+// otherwise appended clauses would become part of the comment
+func stripTrailing(stm string) string {
+	tokens := tokenize(stm)
+	for len(tokens) > 0 {
+		last := tokens[len(tokens)-1]
+		if last.kind != tokenComment && strings.Trim(last.text, " \t\r\n;") != "" {
+			break
+		}
+		tokens = tokens[:len(tokens)-1]
+	}
+	var out strings.Builder
+	for _, t := range tokens {
+		out.WriteString(t.text)
+	}
+	return strings.TrimRight(out.String(), " \t\r\n;")
+}
+
+// helper function to rewrite SELECT statement to fetch only rows within
+// index-limit range. It returns false if statement should not be rewritten,
+// e.g. pushdown mode is off, statement has its own LIMIT or locking clause.
+func pushdownStatement(stm string) (string, bool) {
+	if !PUSHDOWN || DIALECT == nil {
+		return stm, false
+	}
+	query := stripTrailing(strings.TrimSpace(stm))
+	lquery := strings.ToLower(query)
+	if !strings.HasPrefix(lquery, "select") && !strings.HasPrefix(lquery, "with") {
+		return stm, false
+	}
+	if limitPattern.MatchString(query) || lockPattern.MatchString(query) {
+		return stm, false
+	}
+	offset, limit := INDEX, 0
+	if LIMIT > 0 {
+		if LIMIT <= INDEX {
+			// nothing to fetch, let client side handle it
+			return stm, false
+		}
+		limit = LIMIT - INDEX
+	}
+	if offset <= 0 && limit <= 0 {
+		return stm, false
+	}
+	return DIALECT.Limit(query, offset, limit), true
+}
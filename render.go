@@ -0,0 +1,276 @@
+package main
+
+// render module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// DB values are converted according to type category of their columns
+// (see TypeCategory of the dialects) such that
+// - text stored as []byte is shown as text
+// - binary data is shown in hex or base64 encoding, see set binary=...
+// - NULL is shown as configurable marker, see set null=...
+// - time values are shown in configurable layout, see set timeformat=...
+// - decimal values are kept as text to preserve their precision
+// - JSON documents are embedded as is into JSON output and indented in pairs format
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// NULLMARKER represents string to show for NULL values
+var NULLMARKER = "NULL"
+
+// BINARYFORMAT represents encoding of binary values, hex or base64
+var BINARYFORMAT = "hex"
+
+// TIMEFORMAT represents Go layout of time values, e.g. 2006-01-02 15:04:05,
+// or one of rfc3339, rfc3339nano, datetime, unix, unixmilli names
+var TIMEFORMAT = time.RFC3339
+
+// DateFormat represents layout of date values
+const DateFormat = "2006-01-02"
+
+// TimestampFormat represents layout of timestamp literals
+const TimestampFormat = "2006-01-02 15:04:05.999999999"
+
+// TimeOfDayFormat represents layout of time of day values
+const TimeOfDayFormat = "15:04:05.999999999"
+
+// TimeFormats represents named time layouts
+var TimeFormats = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"datetime":    "2006-01-02 15:04:05",
+	"unix":        "unix",
+	"unixmilli":   "unixmilli",
+}
+
+// SHOWTYPES defines if database types of columns should be shown
+var SHOWTYPES bool
+
+// Column represents DB column metadata
+type Column struct {
+	Name      string `json:"name"`                // lower case column name
+	Label     string `json:"-"`                   // original column name
+	Type      string `json:"type"`                // database type name
+	Category  string `json:"category"`            // generic type category
+	Nullable  *bool  `json:"nullable,omitempty"`  // column nullability if known
+	Length    *int64 `json:"length,omitempty"`    // length of variable length types
+	Precision *int64 `json:"precision,omitempty"` // precision of decimal types
+	Scale     *int64 `json:"scale,omitempty"`     // scale of decimal types
+}
+
+// Describe returns column type description, e.g. VARCHAR(255) NOT NULL
+func (c Column) Describe() string {
+	desc := c.Type
+	if desc == "" {
+		desc = "UNKNOWN"
+	}
+	if !strings.Contains(desc, "(") {
+		if c.Precision != nil && c.Scale != nil {
+			desc = fmt.Sprintf("%s(%d,%d)", desc, *c.Precision, *c.Scale)
+		} else if c.Length != nil {
+			desc = fmt.Sprintf("%s(%d)", desc, *c.Length)
+		}
+	}
+	if c.Nullable != nil {
+		if *c.Nullable {
+			desc += " NULL"
+		} else {
+			desc += " NOT NULL"
+		}
+	}
+	return desc
+}
+
+// Binary represents binary DB value
+type Binary []byte
+
+// String implements Stringer interface
+func (b Binary) String() string {
+	if BINARYFORMAT == "base64" {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return "0x" + hex.EncodeToString(b)
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (b Binary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// Decimal represents fixed point DB value kept as text to preserve its precision
+type Decimal string
+
+// String implements Stringer interface
+func (d Decimal) String() string {
+	return string(d)
+}
+
+// MarshalJSON implements json.Marshaler interface, decimal is written as JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if json.Valid([]byte(d)) {
+		return []byte(d), nil
+	}
+	return json.Marshal(string(d))
+}
+
+// JSONText represents JSON document stored in DB
+type JSONText string
+
+// String implements Stringer interface, JSON document is written in compact form
+func (j JSONText) String() string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(j)); err != nil {
+		return string(j)
+	}
+	return buf.String()
+}
+
+// MarshalJSON implements json.Marshaler interface, valid JSON is embedded as is
+func (j JSONText) MarshalJSON() ([]byte, error) {
+	if json.Valid([]byte(j)) {
+		return []byte(j.String()), nil
+	}
+	return json.Marshal(string(j))
+}
+
+// Indent returns indented JSON document, every line but first one gets given prefix
+func (j JSONText) Indent(prefix string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(j), prefix, "  "); err != nil {
+		return string(j)
+	}
+	return buf.String()
+}
+
+// valueConverter may be implemented by dialects which need to convert
+// driver specific values, e.g. DuckDB decimals
+type valueConverter interface {
+	ConvertValue(val interface{}, col Column) interface{}
+}
+
+// helper function to obtain metadata of query columns
+func queryColumns(rows *sql.Rows) ([]Column, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	var columns []Column
+	for _, ct := range types {
+		col := Column{
+			Name:  strings.ToLower(ct.Name()),
+			Label: ct.Name(),
+			Type:  ct.DatabaseTypeName(),
+		}
+		if DIALECT != nil {
+			col.Category = DIALECT.TypeCategory(col.Type)
+		} else {
+			col.Category = typeCategory(col.Type)
+		}
+		if nullable, ok := ct.Nullable(); ok {
+			col.Nullable = &nullable
+		}
+		// unlimited length, e.g. of TEXT type, is reported as max int64
+		if length, ok := ct.Length(); ok && length != math.MaxInt64 {
+			col.Length = &length
+		}
+		if precision, scale, ok := ct.DecimalSize(); ok {
+			col.Precision = &precision
+			col.Scale = &scale
+		}
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// helper function to convert scanned DB value according to its column type
+func columnValue(val interface{}, col Column) interface{} {
+	if c, ok := DIALECT.(valueConverter); ok {
+		val = c.ConvertValue(val, col)
+	}
+	switch v := val.(type) {
+	case []byte:
+		switch col.Category {
+		case TypeBinary:
+			return Binary(v)
+		case TypeJSON:
+			return JSONText(v)
+		case TypeDecimal:
+			return Decimal(v)
+		}
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return Binary(v)
+	case string:
+		switch col.Category {
+		case TypeJSON:
+			return JSONText(v)
+		case TypeDecimal:
+			return Decimal(v)
+		}
+	case time.Time:
+		return timeValue(v, col.Category)
+	}
+	return val
+}
+
+// helper function to format time value according to time format settings
+func timeValue(t time.Time, category string) interface{} {
+	layout := TIMEFORMAT
+	if name, ok := TimeFormats[strings.ToLower(layout)]; ok {
+		layout = name
+	}
+	if category == TypeTimeOfDay {
+		// time of day has no date, i.e. it is not point in time
+		return t.Format(TimeOfDayFormat)
+	}
+	switch layout {
+	case "unix":
+		return t.Unix()
+	case "unixmilli":
+		return t.UnixMilli()
+	}
+	if category == TypeDate {
+		return t.Format(DateFormat)
+	}
+	return t.Format(layout)
+}
+
+// helper function to convert integer value with given scale to decimal, e.g. 12345 and 2 to 123.45
+func decimalString(val *big.Int, scale int) Decimal {
+	if val == nil {
+		return ""
+	}
+	s := new(big.Int).Abs(val).String()
+	if scale > 0 {
+		if len(s) <= scale {
+			s = strings.Repeat("0", scale-len(s)+1) + s
+		}
+		s = s[:len(s)-scale] + "." + s[len(s)-scale:]
+	}
+	if val.Sign() < 0 {
+		s = "-" + s
+	}
+	return Decimal(s)
+}
+
+// helper function to set time format, it accepts named formats or Go layouts
+func setTimeFormat(format string) error {
+	format = strings.Trim(format, " ")
+	if format == "" {
+		return fmt.Errorf("empty time format, use one of rfc3339, rfc3339nano, datetime, unix, unixmilli or Go layout")
+	}
+	TIMEFORMAT = format
+	return nil
+}
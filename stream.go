@@ -0,0 +1,384 @@
+package main
+
+// stream module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// The rows format aligns record values into columns. Instead of buffering
+// the whole output (as text/tabwriter does) we buffer only a sample of first
+// records, size columns from it and stream all other records right away.
+// Values of later records which are wider than sampled ones are not truncated,
+// they only shift the rest of their line, unless columns are limited by
+// set maxwidth=N or fitted to terminal width.
+//
+// Cells which exceed their column width are either truncated with ellipsis
+// or wrapped into multiple lines, see set wrap=on|off|word.
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+	"golang.org/x/term"
+)
+
+// SampleSize represents number of first records used to size columns in rows format
+var SampleSize = 100
+
+// MAXWIDTH represents maximum width of column in rows format, 0 means no limit
+var MAXWIDTH = 0
+
+// WRAP represents wrapping mode of wide cells: off (truncate), on or word
+var WRAP = "off"
+
+// FIT defines if rows format should fit into terminal width
+var FIT = true
+
+// AUTOPAIRS defines if records should be shown in pairs format when they do not fit into terminal
+var AUTOPAIRS bool
+
+// Ellipsis represents suffix of truncated cells
+const Ellipsis = "…"
+
+// rowsPrinter prints records in rows format with bounded memory
+type rowsPrinter struct {
+	w      *bufio.Writer // buffered output
+	keys   []string      // column names
+	types  []string      // column types shown under column names
+	widths []int         // column widths
+	limits []int         // maximum widths of cell content, 0 means no limit
+	sample [][]string    // buffered values of sampled records
+	size   int           // number of records to sample
+	count  int           // number of streamed records
+	term   int           // terminal width, 0 if output is not terminal
+
+	streaming bool // sample is printed and records are streamed
+	pairs     bool // records do not fit into terminal and are shown as pairs
+}
+
+// helper function to create new rows printer for given column names
+func newRowsPrinter(out io.Writer, keys []string, size int) *rowsPrinter {
+	if size < 1 {
+		size = 1
+	}
+	p := &rowsPrinter{
+		w:      bufio.NewWriter(out),
+		keys:   keys,
+		widths: make([]int, len(keys)),
+		limits: make([]int, len(keys)),
+		size:   size,
+		term:   terminalWidth(out),
+	}
+	p.measure(keys)
+	return p
+}
+
+// helper function to return width of terminal of given output, 0 if it is not terminal
+func terminalWidth(out io.Writer) int {
+	if !FIT {
+		return 0
+	}
+	f, ok := out.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// SetTypes sets column types to show under column names
+func (p *rowsPrinter) SetTypes(types []string) {
+	p.types = types
+	p.measure(types)
+}
+
+// Print prints record values, until sample is collected values are buffered
+func (p *rowsPrinter) Print(vals []string) {
+	if !p.streaming {
+		if len(p.sample) < p.size {
+			p.measure(vals)
+			p.sample = append(p.sample, append([]string(nil), vals...))
+			return
+		}
+		p.flushSample()
+	}
+	p.writeRecord(vals)
+	p.count++
+	// flush output in batches to show records as they arrive
+	if p.count%p.size == 0 {
+		p.w.Flush()
+	}
+}
+
+// Flush prints buffered records and flushes output
+func (p *rowsPrinter) Flush() {
+	if !p.streaming && len(p.sample) > 0 {
+		p.flushSample()
+	}
+	p.w.Flush()
+}
+
+// helper function to update column widths from given values
+func (p *rowsPrinter) measure(vals []string) {
+	for i, val := range vals {
+		if i >= len(p.widths) {
+			break
+		}
+		width := cellWidth(val) + Padding
+		if MAXWIDTH > 0 && width > MAXWIDTH+Padding {
+			width = MAXWIDTH + Padding
+		}
+		if width < MinWidth {
+			width = MinWidth
+		}
+		if width > p.widths[i] {
+			p.widths[i] = width
+		}
+	}
+}
+
+// helper function to print header and sampled records
+func (p *rowsPrinter) flushSample() {
+	p.fit()
+	if !p.pairs {
+		p.writeLine(p.keys)
+		if p.types != nil {
+			p.writeLine(p.types)
+		}
+	}
+	for _, vals := range p.sample {
+		p.writeRecord(vals)
+	}
+	// release sample memory, all further records are streamed
+	p.sample = nil
+	p.streaming = true
+	p.w.Flush()
+}
+
+// helper function to set column limits according to maxwidth and terminal width
+func (p *rowsPrinter) fit() {
+	if MAXWIDTH > 0 {
+		for i := range p.limits {
+			p.limits[i] = MAXWIDTH
+		}
+	}
+	if p.term <= 0 || len(p.widths) == 0 {
+		return
+	}
+	total := 0
+	for _, width := range p.widths {
+		total += width
+	}
+	if total > p.term && AUTOPAIRS {
+		p.pairs = true
+		return
+	}
+	// shrink widest columns until record fits into terminal
+	minWidth := MinWidth
+	if minWidth < 1+Padding {
+		minWidth = 1 + Padding
+	}
+	for total > p.term {
+		widest := 0
+		for i, width := range p.widths {
+			if width > p.widths[widest] {
+				widest = i
+			}
+		}
+		if p.widths[widest] <= minWidth {
+			break
+		}
+		p.widths[widest]--
+		total--
+	}
+	// all further records are limited to fitted column widths
+	for i, width := range p.widths {
+		p.limits[i] = width - Padding
+		if p.limits[i] < 1 {
+			p.limits[i] = 1
+		}
+	}
+}
+
+// helper function to write record values either as row or as pairs
+func (p *rowsPrinter) writeRecord(vals []string) {
+	if p.pairs {
+		p.writePairs(vals)
+		return
+	}
+	p.writeLine(vals)
+}
+
+// helper function to write record values as key: value pairs
+func (p *rowsPrinter) writePairs(vals []string) {
+	labels := p.keys
+	if p.types != nil {
+		labels = make([]string, len(p.keys))
+		for i, key := range p.keys {
+			labels[i] = key + " [" + p.types[i] + "]"
+		}
+	}
+	var maxKeyLength int
+	for _, label := range labels {
+		if w := textWidth(label); w > maxKeyLength {
+			maxKeyLength = w
+		}
+	}
+	p.w.WriteString("\n")
+	for i, val := range vals {
+		p.w.WriteString(labels[i])
+		p.w.WriteString(strings.Repeat(" ", maxKeyLength-textWidth(labels[i])))
+		p.w.WriteString(": ")
+		p.w.WriteString(val)
+		p.w.WriteString("\n")
+	}
+}
+
+// helper function to write single row of aligned values,
+// wrapped cells span multiple lines
+func (p *rowsPrinter) writeLine(vals []string) {
+	cells := make([][]string, len(vals))
+	lines := 1
+	for i, val := range vals {
+		limit := 0
+		if i < len(p.limits) {
+			limit = p.limits[i]
+		}
+		cells[i] = cellLines(val, limit)
+		if len(cells[i]) > lines {
+			lines = len(cells[i])
+		}
+	}
+	for line := 0; line < lines; line++ {
+		for i := range vals {
+			var text string
+			if line < len(cells[i]) {
+				text = cells[i][line]
+			}
+			p.w.WriteString(text)
+			// last column is not padded
+			if i == len(vals)-1 || i >= len(p.widths) {
+				continue
+			}
+			pad := p.widths[i] - textWidth(text)
+			if pad < 1 {
+				pad = 1
+			}
+			for ; pad > 0; pad-- {
+				p.w.WriteByte(' ')
+			}
+		}
+		p.w.WriteString("\n")
+	}
+}
+
+// helper function to return display width of cell value, i.e. its longest line
+func cellWidth(val string) int {
+	if WRAP == "off" || !strings.Contains(val, "\n") {
+		return textWidth(val)
+	}
+	var width int
+	for _, line := range strings.Split(val, "\n") {
+		if w := textWidth(line); w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// helper function to split cell value into lines which fit into given limit
+func cellLines(val string, limit int) []string {
+	val = strings.ReplaceAll(val, "\t", " ")
+	if WRAP == "off" {
+		// multi-line values are shown in single line
+		val = strings.ReplaceAll(strings.ReplaceAll(val, "\r\n", " "), "\n", " ")
+		return []string{truncate(val, limit)}
+	}
+	var out []string
+	for _, line := range strings.Split(val, "\n") {
+		if limit <= 0 || textWidth(line) <= limit {
+			out = append(out, line)
+			continue
+		}
+		if WRAP == "word" {
+			out = append(out, wrapWords(line, limit)...)
+		} else {
+			out = append(out, wrapRunes(line, limit)...)
+		}
+	}
+	return out
+}
+
+// helper function to return display width of text, i.e. wide (CJK, emoji)
+// characters take two columns and combining marks do not take any column
+func textWidth(val string) int {
+	return runewidth.StringWidth(val)
+}
+
+// helper function to split value at grapheme cluster boundary such that its
+// head fits into given display width, head has at least one cluster
+func splitWidth(val string, limit int) (string, string) {
+	var width, idx int
+	g := uniseg.NewGraphemes(val)
+	for g.Next() {
+		w := runewidth.StringWidth(g.Str())
+		if width+w > limit && idx > 0 {
+			break
+		}
+		width += w
+		_, idx = g.Positions()
+	}
+	return val[:idx], val[idx:]
+}
+
+// helper function to truncate value to given display width ending it with ellipsis
+func truncate(val string, limit int) string {
+	if limit <= 0 || textWidth(val) <= limit {
+		return val
+	}
+	head, _ := splitWidth(val, limit-textWidth(Ellipsis))
+	return head + Ellipsis
+}
+
+// helper function to split value into chunks of given display width
+func wrapRunes(val string, limit int) []string {
+	var out []string
+	for textWidth(val) > limit {
+		head, tail := splitWidth(val, limit)
+		out = append(out, head)
+		val = tail
+	}
+	return append(out, val)
+}
+
+// helper function to wrap value at word boundaries, too long words are split
+func wrapWords(val string, limit int) []string {
+	var out []string
+	var line string
+	for _, word := range strings.Fields(val) {
+		for textWidth(word) > limit {
+			if line != "" {
+				out = append(out, line)
+				line = ""
+			}
+			head, tail := splitWidth(word, limit)
+			out = append(out, head)
+			word = tail
+		}
+		switch {
+		case line == "":
+			line = word
+		case textWidth(line)+1+textWidth(word) <= limit:
+			line += " " + word
+		default:
+			out = append(out, line)
+			line = word
+		}
+	}
+	return append(out, line)
+}
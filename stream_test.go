@@ -0,0 +1,110 @@
+package main
+
+// stream module benchmarks
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// BenchRows represents number of rows of generated benchmark table
+const BenchRows = 10000
+
+// helper function to create temporary SQLite database with generated table
+func benchDB(b *testing.B) *sql.DB {
+	b.Helper()
+	dburi := fmt.Sprintf("sqlite://%s", filepath.Join(b.TempDir(), "bench.db"))
+	db, _, err := dbInit(dburi)
+	if err != nil {
+		b.Skipf("sqlite is not available: %v", err)
+	}
+	b.Cleanup(func() { db.Close() })
+	stm := `CREATE TABLE bench (id INTEGER, name TEXT, value REAL, note TEXT)`
+	if _, err := db.Exec(stm); err != nil {
+		b.Fatal(err)
+	}
+	stm = fmt.Sprintf(`INSERT INTO bench
+		WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n+1 FROM seq WHERE n < %d)
+		SELECT n, 'name-' || n, n * 1.5, CASE WHEN n %% 7 = 0 THEN NULL ELSE 'note of row ' || n END FROM seq`,
+		BenchRows)
+	if _, err := db.Exec(stm); err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+// helper function to stream all rows of benchmark table in given DB format
+func benchStream(b *testing.B, db *sql.DB, format string) {
+	b.Helper()
+	oldFormat := DBFORMAT
+	DBFORMAT = format
+	defer func() { DBFORMAT = oldFormat }()
+
+	query := "SELECT * FROM bench"
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rows, err := db.Query(query)
+		if err != nil {
+			b.Fatal(err)
+		}
+		columns, err := queryColumns(rows)
+		if err != nil {
+			b.Fatal(err)
+		}
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for j := range columns {
+			valuePtrs[j] = &values[j]
+		}
+		printer := newRecordPrinter(io.Discard, query, columns, SampleSize)
+		for rows.Next() {
+			if err := rows.Scan(valuePtrs...); err != nil {
+				b.Fatal(err)
+			}
+			printer.PrintValues(values)
+		}
+		printer.Flush()
+		if err := rows.Err(); err != nil {
+			b.Fatal(err)
+		}
+		rows.Close()
+	}
+}
+
+// BenchmarkRowsPrinter benchmarks streaming of generated table in rows format
+func BenchmarkRowsPrinter(b *testing.B) {
+	benchStream(b, benchDB(b), "rows")
+}
+
+// BenchmarkPairsPrinter benchmarks streaming of generated table in pairs format
+func BenchmarkPairsPrinter(b *testing.B) {
+	benchStream(b, benchDB(b), "pairs")
+}
+
+// BenchmarkJSONPrinter benchmarks streaming of generated table in json format
+func BenchmarkJSONPrinter(b *testing.B) {
+	benchStream(b, benchDB(b), "json")
+}
+
+// BenchmarkRowsPrinterValues benchmarks rows printer without DB access
+func BenchmarkRowsPrinterValues(b *testing.B) {
+	keys := []string{"id", "name", "value", "note"}
+	vals := make([][]string, BenchRows)
+	for i := range vals {
+		vals[i] = []string{fmt.Sprint(i), fmt.Sprintf("name-%d", i), fmt.Sprint(float64(i) * 1.5), "note"}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		printer := newRowsPrinter(io.Discard, keys, SampleSize)
+		for _, v := range vals {
+			printer.Print(v)
+		}
+		printer.Flush()
+	}
+}
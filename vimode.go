@@ -0,0 +1,129 @@
+package main
+
+// vi mode module
+// Copyright (c) 2022 - Valentin Kuznetsov <vkuznet@gmail.com>
+//
+// vi editing mode of the prompt, see set editmode=vi. The prompt starts in
+// insert mode, Esc switches to normal mode where the following commands are
+// supported:
+// - motions: h, l, w, b, e, 0, $
+// - editing: x, dw, cw, dd, cc, D, C, u (undo)
+// - insert mode: i, a, I, A
+// - history: k, j and / (search)
+// - v opens current statement in $EDITOR
+
+// EDITMODE represents key bindings of the prompt, emacs (default) or vi
+var EDITMODE = "emacs"
+
+// viAction represents result of vi normal mode command
+type viAction int
+
+const (
+	viRedraw      viAction = iota // line buffer is updated
+	viSearch                      // history search is requested
+	viEdit                        // editing in external editor is requested
+	viHistoryPrev                 // previous history entry is requested
+	viHistoryNext                 // next history entry is requested
+)
+
+// viMode represents state of vi editing mode
+type viMode struct {
+	normal   bool   // normal mode, otherwise insert mode
+	operator string // pending operator, d or c
+}
+
+// Escape switches to normal mode, cursor moves onto last inserted character
+func (v *viMode) Escape(line *LineBuffer) {
+	if !v.normal {
+		v.normal = true
+		line.Left()
+	}
+	v.operator = ""
+}
+
+// Insert switches to insert mode, the line is saved such that whole insertion is undone at once
+func (v *viMode) Insert(line *LineBuffer) {
+	line.Save()
+	v.normal = false
+	v.operator = ""
+}
+
+// Command executes normal mode command on line buffer
+func (v *viMode) Command(line *LineBuffer, cmd string) viAction {
+	if v.operator != "" {
+		op := v.operator
+		v.operator = ""
+		switch op + cmd {
+		case "dw":
+			line.Save()
+			line.ViDeleteWord()
+		case "cw":
+			v.Insert(line)
+			line.ViChangeWord()
+		case "dd":
+			line.Save()
+			line.Home()
+			line.KillToEnd()
+		case "cc":
+			v.Insert(line)
+			line.Home()
+			line.KillToEnd()
+		}
+		if v.normal {
+			line.ViClamp()
+		}
+		return viRedraw
+	}
+	switch cmd {
+	case "h":
+		line.Left()
+	case "l":
+		line.Right()
+	case "w":
+		line.ViWordNext()
+	case "b":
+		line.ViWordPrev()
+	case "e":
+		line.ViWordEnd()
+	case "0":
+		line.Home()
+	case "$":
+		line.End()
+	case "x":
+		line.Save()
+		line.Delete()
+	case "d", "c":
+		v.operator = cmd
+	case "D":
+		line.Save()
+		line.KillToEnd()
+	case "C":
+		v.Insert(line)
+		line.KillToEnd()
+	case "i":
+		v.Insert(line)
+	case "a":
+		v.Insert(line)
+		line.Right()
+	case "I":
+		v.Insert(line)
+		line.Home()
+	case "A":
+		v.Insert(line)
+		line.End()
+	case "u":
+		line.Undo()
+	case "k":
+		return viHistoryPrev
+	case "j":
+		return viHistoryNext
+	case "/":
+		return viSearch
+	case "v":
+		return viEdit
+	}
+	if v.normal {
+		line.ViClamp()
+	}
+	return viRedraw
+}